@@ -5,89 +5,267 @@ import (
 	"sahaj/internal"
 	"sahaj/pkg/parking"
 	"sort"
-	"strings"
 	"time"
 )
 
 type ParkingLot struct {
-	parking   internal.Parking     // base parking model
-	record    map[string]time.Time // parking record
-	receiptNo uint                 // tracks upcoming receipt
-	padWidth  uint                 // for printing receipt & ticket number
+	parking  internal.Parking  // base parking model
+	record   internal.Records  // parking record
+	booking  internal.Booking  // reservations
+	receipts internal.Receipts // settled receipts & their adjustments
+	ticketNo uint              // tracks upcoming ticket
+	padWidth uint              // for printing receipt & ticket number
 }
 
-func New(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) *ParkingLot {
-	// Bus/Truck are not allowed at Airport
-	if _, ok := inventory[parking.VehicleType_BusTruck]; ok {
-		panic(parking.VehicleType_BusTruck.String() + " can not be parked @ " + parking.ModelType_Airport.String())
+func New(id string, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory, opts ...internal.Option) (*ParkingLot, error) {
+	base, err := internal.NewParking(id, parking.ModelType_Airport, fee, inventory, opts...)
+	if err != nil {
+		return nil, err
 	}
 	return &ParkingLot{
-		parking: internal.Parking{
-			Inventory: inventory,
-			Fee:       fee,
+		parking: base,
+		record:  internal.NewRecords(nil),
+		booking: internal.Booking{
+			ReleaseAfter: base.ReservationRelease,
+			EarlyArrival: base.EarlyArrival,
+			HoldAhead:    base.ReservationHold,
+			PadWidth:     3,
 		},
-		record:    map[string]time.Time{},
-		receiptNo: 0,
-		padWidth:  3,
-	}
+		receipts: internal.NewReceipts(id, 3, base.Ledger),
+		ticketNo: 0,
+		padWidth: 3,
+	}, nil
+}
+
+func (p *ParkingLot) GetID() string {
+	return p.parking.ID
 }
 
 func (p *ParkingLot) GetType() parking.ModelType {
 	return parking.ModelType_Airport
 }
 
+// Occupancy takes a snapshot of the spots of the Parking Lot
+func (p *ParkingLot) Occupancy() parking.Occupancy {
+	return p.parking.Occupancy(p.record, &p.booking, p.parking.Now())
+}
+
+// OccupancyChanges returns the occupancy of the Parking Lot every time a vehicle parked or exited
+func (p *ParkingLot) OccupancyChanges() []parking.OccupancyChange {
+	return p.parking.OccupancyChanges()
+}
+
+// Ticket returns the ticket of a vehicle still parked
+func (p *ParkingLot) Ticket(ticketNo string) (parking.Ticket, error) {
+	return p.parking.Ticket(p.record, ticketNo)
+}
+
+// Tickets lists the tickets of vehicles still parked matching the query
+func (p *ParkingLot) Tickets(query parking.TicketQuery) parking.TicketPage {
+	return p.parking.Tickets(p.record, query)
+}
+
 func (p *ParkingLot) Do(action parking.Action) parking.Result {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
 	res := parking.Result{}
 	switch action.ActionType {
 	case parking.ActionType_Park:
 		res.ParkingTicket, res.Err = p.generateParkingTicket(action)
+		if res.Err == nil && p.parking.PaymentTiming == parking.PaymentTiming_OnEntry {
+			res.ParkingReceipt, res.Err = p.generateEntryReceipt(res.ParkingTicket)
+		}
 	case parking.ActionType_UnPark:
 		res.ParkingReceipt, res.Err = p.generateParkingReceipt(action)
+	case parking.ActionType_Reserve:
+		res.Reservation, res.ParkingReceipt, res.Err = p.reserve(action)
+	case parking.ActionType_CancelReservation:
+		res.Reservation, res.Adjustment, res.Err = p.cancel(action)
+	case parking.ActionType_ModifyReservation:
+		res.Reservation, res.Err = p.booking.Modify(action, p.parking.Inventory, p.parking.Now())
+	case parking.ActionType_Refund:
+		res.Adjustment, res.Err = p.receipts.Refund(action, p.parking.Gateway, p.parking.Now())
 	default:
 		res.Err = parking.ErrInvalidAction
 	}
+	res.Err = p.parking.WrapError(action, res.Err)
+	p.parking.LogResult(action, res)
+	p.parking.EmitResult(action, res, p.record, p.parking.Now())
 	return res
 }
 
+// CheckOverstays emits the vehicles parked longer than the maximum stay by now, for a sweep
+// to report them while no action is done at the Parking Lot
+func (p *ParkingLot) CheckOverstays() {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
+}
+
+// SetFee changes the tariff of the Parking Lot, stays in progress are charged as per it on exit
+func (p *ParkingLot) SetFee(fee parking.Fee) {
+	p.parking.SetFee(fee, p.parking.Now())
+}
+
+// reserve books the spot, collecting the prepaid amount through the gateway & issuing its receipt
+// if the reservation is paid in advance
+func (p *ParkingLot) reserve(action parking.Action) (*parking.Reservation, *parking.Receipt, error) {
+	now := p.parking.Now()
+	if !action.Prepay {
+		reservation, err := p.booking.Reserve(action, p.parking.Inventory, now, 0)
+		return reservation, nil, err
+	}
+	prepaid, err := calculatePrepaid(action, p.parking.Fee, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the spot must be free before the prepaid amount is collected
+	if err := p.booking.Available(action, p.parking.Inventory, now); err != nil {
+		return nil, nil, err
+	}
+	paymentID, err := p.parking.Settle(action, prepaid)
+	if err != nil {
+		return nil, nil, err
+	}
+	reservation, err := p.booking.Reserve(action, p.parking.Inventory, now, prepaid)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt := &parking.Receipt{
+		ReservationNumber: reservation.ReservationNumber,
+		VehicleType:       reservation.VehicleType,
+		EntryDateTime:     reservation.From,
+		ExitDateTime:      reservation.Till,
+		IssuedAt:          now,
+		Fees:              prepaid,
+		PaymentID:         paymentID,
+		LineItems:         []parking.LineItem{{Description: "Prepaid reservation", Amount: int(prepaid)}},
+	}
+	err = p.receipts.Issue(receipt)
+	paid, _ := p.booking.Pay(reservation.ReservationNumber, *receipt)
+	return &paid, receipt, err
+}
+
+// cancel cancels the reservation, refunding what is left of its prepaid amount if any
+func (p *ParkingLot) cancel(action parking.Action) (*parking.Reservation, *parking.Adjustment, error) {
+	now := p.parking.Now()
+	reservation, err := p.booking.Booked(action.ReservationNumber, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	var adjustment *parking.Adjustment
+	if receipt, ok := p.receipts.Get(reservation.ReceiptNumber); ok && receipt.Refunded < receipt.Fees {
+		operatorID := action.OperatorID
+		if operatorID == "" {
+			operatorID = internal.SystemOperator
+		}
+		adjustment, err = p.receipts.Refund(parking.Action{
+			ReceiptNumber: &receipt.ReceiptNumber,
+			Reason:        parking.AdjustmentReason_Cancellation,
+			OperatorID:    operatorID,
+		}, p.parking.Gateway, now)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	cancelled, err := p.booking.Cancel(action, now)
+	return cancelled, adjustment, err
+}
+
 func (p *ParkingLot) generateParkingTicket(action parking.Action) (*parking.Ticket, error) {
 	inv, ok := p.parking.Inventory[action.VehicleType]
 	if !ok {
-		return nil, parking.ErrVehicleNotAllowed
+		return nil, parking.NewError(parking.ErrVehicleNotAllowed, p.parking.ID, "", action.VehicleType.String()+" has no spots")
 	}
-	if len(p.record) < int(inv.Total) {
-		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), len(p.record)+1)
-		key := getRecordKey(tktNo, action.VehicleType)
-		p.record[key] = time.Now()
-		return &parking.Ticket{
-			TicketNumber:  tktNo,
-			SpotNumber:    uint(len(p.record)),
-			EntryDateTime: time.Now(),
-		}, nil
+	now := p.parking.Now()
+	var reservationNo string
+	if action.ReservationNumber != nil {
+		reservationNo = *action.ReservationNumber
+		if err := p.booking.Open(reservationNo, action.VehicleType, now); err != nil {
+			return nil, err
+		}
 	}
-	return nil, parking.ErrNoSpace
+	// spots held for reservations can only be taken by their own vehicle
+	occupied := p.record.Occupied(action.VehicleType) + p.booking.Held(action.VehicleType, now, reservationNo)
+	if occupied < inv.Total {
+		passID, err := p.parking.UsePass(action, now)
+		if err != nil {
+			return nil, err
+		}
+		// surge multiplier is locked at entry, by the occupancy of the vehicle type before it parks
+		surge := internal.SurgeAt(p.parking.Fee, action.VehicleType, float64(occupied)/float64(inv.Total))
+		// the first rate band is paid on entry, unless covered by a pass or a prepaid reservation
+		var paid uint
+		if p.parking.PaymentTiming == parking.PaymentTiming_OnEntry && passID == "" && !p.prepaid(reservationNo) {
+			paid, err = calculateFee(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: action.VehicleType}, p.parking.Fee, now, now.Add(internal.EntryStay(p.parking.Fee, action.VehicleType)))
+			if err != nil {
+				return nil, err
+			}
+			paid += internal.Surcharge(paid, surge)
+		}
+		paymentID, err := p.parking.Settle(action, paid)
+		if err != nil {
+			return nil, err
+		}
+		p.ticketNo++
+		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), p.ticketNo)
+		rec := &internal.Record{
+			VehicleType:       action.VehicleType,
+			Plate:             action.Plate,
+			SpotNumber:        p.record.FreeSpot(action.VehicleType, inv.Total),
+			EntryDateTime:     now,
+			ReservationNumber: reservationNo,
+			PassID:            passID,
+			Paid:              paid,
+			PaymentID:         paymentID,
+			Surge:             surge,
+		}
+		p.record.Add(tktNo, rec)
+		p.parking.RecordOccupancy(p.record, action.VehicleType, now)
+		p.booking.Arrive(reservationNo)
+		ticket := rec.Ticket(tktNo)
+		return &ticket, nil
+	}
+	return nil, parking.NewError(parking.ErrNoSpace, p.parking.ID, "", fmt.Sprintf("%d of %d %s spots occupied or reserved", occupied, inv.Total, action.VehicleType))
+}
+
+// generateEntryReceipt issues the receipt of the amount paid on entry
+func (p *ParkingLot) generateEntryReceipt(ticket *parking.Ticket) (*parking.Receipt, error) {
+	rec, _ := p.record.Get(ticket.TicketNumber)
+	return p.receipts.IssueEntry(*ticket, rec.PaymentID)
 }
 
 func (p *ParkingLot) generateParkingReceipt(action parking.Action) (*parking.Receipt, error) {
 	if action.TicketNumer == nil {
 		return nil, parking.ErrInvalidTicket
 	}
-	key := getRecordKey(*action.TicketNumer, action.VehicleType)
-	if parkingTime, ok := p.record[key]; ok {
-		fee, err := calculateFee(action, p.parking.Fee, parkingTime, time.Now())
-		if err != nil {
-			return nil, err
-		}
-		p.receiptNo++
-		receipt := &parking.Receipt{
-			ReceiptNumber: fmt.Sprintf(fmt.Sprintf("R-%%0%dd", p.padWidth), p.receiptNo),
-			EntryDateTime: parkingTime,
-			ExitDateTime:  time.Now(),
-			Fees:          fee,
+	rec, err := p.record.Lookup(*action.TicketNumer, action.VehicleType)
+	if err != nil {
+		return nil, err
+	}
+	exitTime := p.parking.Now()
+	// stay is covered by the pass till it expires, only the stay past it is charged
+	entryTime, covered := p.parking.ChargedFrom(rec)
+	var prepaid uint
+	if reservation, ok := p.booking.Get(rec.ReservationNumber); ok && reservation.Prepaid > 0 {
+		prepaid = reservation.Prepaid
+		if !covered {
+			// stay is paid till the end of the reservation window, only overstay is charged.
+			// Arriving early is only allowed within the early arrival of the booking, which is not charged
+			entryTime = reservation.Till
 		}
-		delete(p.record, key)
-		return receipt, nil
 	}
-	return nil, parking.ErrVehicleMismatch
+	charge, err := p.parking.Charge(calculateFee, action, entryTime, exitTime)
+	if err != nil {
+		return nil, err
+	}
+	if surcharge := internal.Surcharge(charge.Fees, rec.Surge); surcharge > 0 {
+		charge.LineItems = append(charge.LineItems, parking.LineItem{Description: fmt.Sprintf("Surge x%g", rec.Surge), Amount: int(surcharge)})
+		charge.Fees += surcharge
+	}
+	charge.Prepaid = prepaid
+	charge.Calculate = func(entryTime, exitTime time.Time) (uint, error) {
+		fees, err := calculateFee(action, p.parking.Fee, entryTime, exitTime)
+		return fees + internal.Surcharge(fees, rec.Surge), err
+	}
+	return p.parking.Checkout(action, p.record, &p.receipts, *action.TicketNumer, charge)
 }
 
 func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime time.Time) (uint, error) {
@@ -98,13 +276,15 @@ func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime ti
 	if exitTime.Before(entryTime) {
 		return fees, parking.ErrExitTime
 	}
-	parkedMinutes := uint(exitTime.Sub(entryTime).Minutes())
+	parkingDuration := exitTime.Sub(entryTime)
+	parkedMinutes := uint(internal.RoundUp(parkingDuration, fee.RoundingMinutes).Minutes())
 	switch fee.Charge {
 	case parking.ChargeType_PerDay: // only PerDay Charge is supported by Airport
 		var rates []parking.Rate
 		for _, vehicle := range fee.Vehicles {
 			if vehicle.Kind == action.VehicleType {
-				rates = vehicle.Rates
+				// day bands are charged whatever the calendar
+				rates, _ = internal.SplitRates(vehicle.Rates)
 				break
 			}
 		}
@@ -144,12 +324,50 @@ func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime ti
 	default:
 		return fees, parking.ErrChargeNotSupported
 	}
-	return fees, nil
+	return internal.ApplyLimits(fee, parkingDuration, fees), nil
+}
+
+// prepaid tells if the reservation was paid in advance
+func (p *ParkingLot) prepaid(reservationNo string) bool {
+	reservation, ok := p.booking.Get(reservationNo)
+	return ok && reservation.Prepaid > 0
 }
 
-func getRecordKey(ticketNo string, vehicleType parking.VehicleType) string {
-	var key strings.Builder
-	key.WriteString(ticketNo)
-	key.WriteString(vehicleType.String())
-	return key.String()
+// calculatePrepaid returns the amount to pay in advance for the reservation window,
+// at the best prepaid rate the time between booking and the window start qualifies for
+func calculatePrepaid(action parking.Action, fee parking.Fee, bookedAt time.Time) (uint, error) {
+	if !action.Till.After(action.From) {
+		return 0, parking.ErrReservationWindow
+	}
+	var prepaidRates []parking.PrepaidRate
+	for _, vehicle := range fee.Vehicles {
+		if vehicle.Kind == action.VehicleType {
+			prepaidRates = vehicle.Prepaid
+			break
+		}
+	}
+
+	// minutes in 1 day
+	oneDay := uint(24 * 60)
+
+	var daysAhead uint
+	if action.From.After(bookedAt) {
+		daysAhead = uint(action.From.Sub(bookedAt).Minutes()) / oneDay
+	}
+	var rate *parking.PrepaidRate
+	for i := range prepaidRates {
+		if prepaidRates[i].DaysAhead <= daysAhead && (rate == nil || prepaidRates[i].DaysAhead > rate.DaysAhead) {
+			rate = &prepaidRates[i]
+		}
+	}
+	if rate == nil {
+		return 0, parking.NewError(parking.ErrChargeNotSupported, "", "", "no prepaid rate for "+action.VehicleType.String())
+	}
+
+	reservedMinutes := uint(action.Till.Sub(action.From).Minutes())
+	totalDays := reservedMinutes / oneDay
+	if reservedMinutes%oneDay > 0 {
+		totalDays++
+	}
+	return totalDays * rate.Rate, nil
 }
@@ -3,6 +3,7 @@ package airport
 import (
 	"sahaj/internal"
 	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 
 func TestNew(t *testing.T) {
 	type args struct {
+		id        string
 		fee       parking.Fee
 		inventory map[parking.VehicleType]internal.Inventory
 	}
@@ -39,7 +41,7 @@ func TestNew(t *testing.T) {
 			},
 		},
 		{
-			name: "trying to instantiate Airport Parking Lot with Bus/Truck as Vehicle should panic",
+			name: "trying to instantiate Airport Parking Lot with Bus/Truck as Vehicle should fail",
 			args: args{
 				fee: parking.Fee{
 					Charge:   parking.ChargeType_PerDay,
@@ -56,19 +58,29 @@ func TestNew(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.want != nil {
-				got := New(tt.args.fee, tt.args.inventory)
-				assert.Equal(t, len(tt.want.parking.Inventory), len(tt.want.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(tt.want.parking.Inventory))
-				assert.Equal(t, tt.want.parking.Fee.Charge, got.parking.Fee.Charge, "ChargeType must match, expected %v got %v", tt.want.parking.Fee.Charge, got.parking.Fee.Charge)
-				assert.Equal(t, len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles), "supported vehicle quantity must match, expected %v got %v", len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles))
-			} else {
-				bool := assert.Panics(t, func() { New(tt.args.fee, tt.args.inventory) }, "must panic")
-				assert.True(t, bool, "New() should have panicked")
+			got, err := New(tt.args.id, tt.args.fee, tt.args.inventory)
+			if tt.want == nil {
+				assert.Nil(t, got, "New() must not create Parking Lot")
+				assert.ErrorIs(t, err, parking.ErrVehicleNotAllowed, "New() must fail with %v, got %v", parking.ErrVehicleNotAllowed, err)
+				return
 			}
+			assert.Nil(t, err, "Err must be nil")
+			assert.Equal(t, len(tt.want.parking.Inventory), len(got.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(got.parking.Inventory))
+			assert.Equal(t, tt.want.parking.Fee.Charge, got.parking.Fee.Charge, "ChargeType must match, expected %v got %v", tt.want.parking.Fee.Charge, got.parking.Fee.Charge)
+			assert.Equal(t, len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles), "supported vehicle quantity must match, expected %v got %v", len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles))
 		})
 	}
 }
 
+// newParkingLot creates a Parking Lot for test cases, panics on invalid inventory
+func newParkingLot(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) ParkingLot {
+	p, err := New("", fee, inventory)
+	if err != nil {
+		panic(err)
+	}
+	return *p
+}
+
 func TestParkingLot_GetType(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -77,7 +89,7 @@ func TestParkingLot_GetType(t *testing.T) {
 	}{
 		{
 			name:   "Type of Parking Lot should always be Airport",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
 			want:   parking.ModelType_Airport,
 		},
 	}
@@ -101,7 +113,7 @@ func TestParkingLot_Do(t *testing.T) {
 	}{
 		{
 			name: "Motercycle should get parked",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -124,7 +136,7 @@ func TestParkingLot_Do(t *testing.T) {
 		},
 		{
 			name: "Motercycle should not get parked if no space in Parking lot",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 0,
 				},
@@ -160,11 +172,15 @@ func TestParkingLot_Do(t *testing.T) {
 						},
 					},
 				},
-				record: map[string]time.Time{
-					"001Motorcycle": internal.Now().Add(-55 * time.Minute),
-				},
-				receiptNo: 0,
-				padWidth:  3,
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-55 * time.Minute),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
 			},
 			args: args{
 				action: parking.Action{
@@ -186,7 +202,7 @@ func TestParkingLot_Do(t *testing.T) {
 		},
 		{
 			name: "Invalid parking Action",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -221,11 +237,15 @@ func TestParkingLot_Do(t *testing.T) {
 						},
 					},
 				},
-				record: map[string]time.Time{
-					"001Motorcycle": internal.Now().Add(-55 * time.Minute),
-				},
-				receiptNo: 0,
-				padWidth:  3,
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-55 * time.Minute),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
 			},
 			args: args{
 				action: parking.Action{
@@ -257,7 +277,376 @@ func TestParkingLot_Do(t *testing.T) {
 				assert.Equal(t, tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber, "ReceiptNumber must match, want %v, got %v", tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber)
 				assert.Equal(t, tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees, "Fees must match, want %v, got %v", tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees)
 			}
-			assert.Equal(t, tt.want.Err, got.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
+			assert.ErrorIs(t, got.Err, tt.want.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
+		})
+	}
+}
+
+func TestParkingLot_Do_Reservation(t *testing.T) {
+	lot := newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 1,
+		},
+	})
+
+	// book the only spot, starting right away
+	got := lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Reserve,
+		VehicleType: parking.VehicleType_CarSuv,
+		From:        time.Now(),
+		Till:        time.Now().Add(72 * time.Hour),
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.NotNil(t, got.Reservation, "nil Reservation")
+	reservationNo := got.Reservation.ReservationNumber
+
+	// walk-in can not take the reserved spot
+	got = lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Park,
+		VehicleType: parking.VehicleType_CarSuv,
+	})
+	assert.ErrorIs(t, got.Err, parking.ErrNoSpace, "walk-in must not get reserved spot")
+
+	// vehicle arriving against the reservation gets the spot
+	got = lot.Do(parking.Action{
+		ActionType:        parking.ActionType_Park,
+		VehicleType:       parking.VehicleType_CarSuv,
+		ReservationNumber: &reservationNo,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.NotNil(t, got.ParkingTicket, "nil ParkingTicket")
+	assert.Equal(t, reservationNo, got.ParkingTicket.ReservationNumber, "ReservationNumber must match")
+	assert.Equal(t, uint(1), got.ParkingTicket.SpotNumber, "SpotNumber must match")
+
+	// reservation can not be used twice
+	got = lot.Do(parking.Action{
+		ActionType:        parking.ActionType_Park,
+		VehicleType:       parking.VehicleType_CarSuv,
+		ReservationNumber: &reservationNo,
+	})
+	assert.ErrorIs(t, got.Err, parking.ErrReservationClosed, "reservation must be closed once vehicle arrived")
+}
+
+func TestParkingLot_Do_PrepaidReservation(t *testing.T) {
+	lot := newParkingLot(parking.Fee{
+		Charge: parking.ChargeType_PerDay,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{
+					{
+						From: 0,
+						Till: 0,
+						Rate: 100,
+					},
+				},
+				Prepaid: []parking.PrepaidRate{
+					{
+						DaysAhead: 0,
+						Rate:      90,
+					},
+				},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 1,
+		},
+	})
+
+	got := lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Reserve,
+		VehicleType: parking.VehicleType_CarSuv,
+		From:        time.Now(),
+		Till:        time.Now().Add(36 * time.Hour),
+		Prepay:      true,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(180), got.Reservation.Prepaid, "2 days must be prepaid")
+	assert.Equal(t, uint(180), got.ParkingReceipt.Fees, "prepaid amount must be collected")
+	assert.Equal(t, got.ParkingReceipt.ReceiptNumber, got.Reservation.ReceiptNumber, "ReceiptNumber must match")
+	assert.NotEmpty(t, got.Reservation.PaymentID, "PaymentID must be set")
+	reservationNo := got.Reservation.ReservationNumber
+
+	got = lot.Do(parking.Action{
+		ActionType:        parking.ActionType_ModifyReservation,
+		VehicleType:       parking.VehicleType_CarSuv,
+		ReservationNumber: &reservationNo,
+		From:              time.Now(),
+		Till:              time.Now().Add(72 * time.Hour),
+	})
+	assert.ErrorIs(t, got.Err, parking.ErrReservationClosed, "prepaid reservation must not be modified")
+
+	got = lot.Do(parking.Action{
+		ActionType:        parking.ActionType_Park,
+		VehicleType:       parking.VehicleType_CarSuv,
+		ReservationNumber: &reservationNo,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+
+	// leaving within the window, nothing more to pay
+	got = lot.Do(parking.Action{
+		ActionType:  parking.ActionType_UnPark,
+		VehicleType: parking.VehicleType_CarSuv,
+		TicketNumer: &got.ParkingTicket.TicketNumber,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(0), got.ParkingReceipt.Fees, "no overstay must be charged")
+	assert.Equal(t, uint(180), got.ParkingReceipt.Prepaid, "Prepaid must match")
+}
+
+func TestParkingLot_Do_CancelPrepaidReservation(t *testing.T) {
+	lot, err := New("airport-1", parking.Fee{
+		Charge: parking.ChargeType_PerDay,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind:    parking.VehicleType_CarSuv,
+				Rates:   []parking.Rate{{From: 0, Till: 0, Rate: 100}},
+				Prepaid: []parking.PrepaidRate{{DaysAhead: 0, Rate: 90}},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {Total: 1},
+	}, internal.WithGateway(payment.NewFakeCard("card-declined")))
+	assert.Nil(t, err, "Err must be nil")
+	reserve := parking.Action{
+		ActionType:    parking.ActionType_Reserve,
+		VehicleType:   parking.VehicleType_CarSuv,
+		From:          time.Now().Add(24 * time.Hour),
+		Till:          time.Now().Add(48 * time.Hour),
+		Prepay:        true,
+		PaymentSource: "card-declined",
+	}
+
+	got := lot.Do(reserve)
+	assert.ErrorIs(t, got.Err, parking.ErrPaymentFailed, "reservation must not be booked unpaid")
+	assert.Nil(t, got.Reservation, "Reservation must be nil")
+
+	reserve.PaymentSource = "card-1"
+	got = lot.Do(reserve)
+	assert.Nil(t, got.Err, "spot must be free after the payment failed")
+	reservationNo := got.Reservation.ReservationNumber
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_CancelReservation, ReservationNumber: &reservationNo})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, parking.ReservationStatus_Cancelled, got.Reservation.Status, "Status must match")
+	assert.Equal(t, uint(90), got.Adjustment.Amount, "prepaid amount must be refunded")
+	assert.Equal(t, parking.AdjustmentReason_Cancellation, got.Adjustment.Reason, "Reason must match")
+	assert.Equal(t, internal.SystemOperator, got.Adjustment.OperatorID, "OperatorID must match")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_CancelReservation, ReservationNumber: &reservationNo})
+	assert.ErrorIs(t, got.Err, parking.ErrReservationClosed, "reservation must not be refunded twice")
+}
+
+func TestParkingLot_Do_PrepaidReservation_EarlyArrival(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	lot, err := New("airport-1", parking.Fee{
+		Charge: parking.ChargeType_PerDay,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind:    parking.VehicleType_CarSuv,
+				Rates:   []parking.Rate{{From: 0, Till: 0, Rate: 100}},
+				Prepaid: []parking.PrepaidRate{{DaysAhead: 0, Rate: 90}},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {Total: 2},
+	}, internal.WithClock(func() time.Time { return now }))
+	assert.Nil(t, err, "Err must be nil")
+
+	reserve := func(from time.Time) string {
+		got := lot.Do(parking.Action{ActionType: parking.ActionType_Reserve, VehicleType: parking.VehicleType_CarSuv, From: from, Till: from.Add(24 * time.Hour), Prepay: true})
+		assert.Nil(t, got.Err, "Err must be nil")
+		return got.Reservation.ReservationNumber
+	}
+	later, soon := reserve(now.Add(72*time.Hour)), reserve(now.Add(10*time.Minute))
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv, ReservationNumber: &later})
+	assert.ErrorIs(t, got.Err, parking.ErrReservationWindow, "vehicle must not arrive days before the window")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv, ReservationNumber: &soon})
+	assert.Nil(t, got.Err, "vehicle must arrive a little before the window")
+
+	now = now.Add(20 * time.Hour)
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &got.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(0), got.ParkingReceipt.Fees, "early arrival within the allowance must not be charged")
+	assert.Equal(t, uint(90), got.ParkingReceipt.Prepaid, "Prepaid must match")
+	assert.Equal(t, []parking.LineItem{
+		{Description: "Parking fee", Amount: 0},
+	}, got.ParkingReceipt.LineItems, "LineItems must match")
+}
+
+func TestParkingLot_Do_PrepaidReservation_EarlyAndLate(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	lot, err := New("airport-1", parking.Fee{
+		Charge:        parking.ChargeType_PerDay,
+		GraceMinutes:  15,
+		MinimumCharge: 30,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind:    parking.VehicleType_CarSuv,
+				Rates:   []parking.Rate{{From: 0, Till: 0, Rate: 20}},
+				Prepaid: []parking.PrepaidRate{{DaysAhead: 0, Rate: 90}},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {Total: 1},
+	}, internal.WithClock(func() time.Time { return now }))
+	assert.Nil(t, err, "Err must be nil")
+
+	from := now.Add(10 * time.Minute)
+	reserved := lot.Do(parking.Action{ActionType: parking.ActionType_Reserve, VehicleType: parking.VehicleType_CarSuv, From: from, Till: from.Add(24 * time.Hour), Prepay: true})
+	assert.Nil(t, reserved.Err, "Err must be nil")
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv, ReservationNumber: &reserved.Reservation.ReservationNumber})
+	assert.Nil(t, parked.Err, "Err must be nil")
+
+	now = from.Add(24*time.Hour + 20*time.Minute)
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &parked.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(30), got.ParkingReceipt.Fees, "minimum charge must apply once, to the overstay only")
+}
+
+func TestParkingLot_Do_Surge(t *testing.T) {
+	lot := newParkingLot(parking.Fee{
+		Charge: parking.ChargeType_PerDay,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{
+					{
+						From: 0,
+						Till: 0,
+						Rate: 100,
+					},
+				},
+				Surge: []parking.Surge{
+					{
+						Occupancy:  0.5,
+						Multiplier: 1.5,
+					},
+				},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 2,
+		},
+	})
+
+	first := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+	assert.Nil(t, first.Err, "Err must be nil")
+	assert.Equal(t, float64(0), first.ParkingTicket.Surge, "no surge below the threshold")
+
+	second := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+	assert.Nil(t, second.Err, "Err must be nil")
+	assert.Equal(t, 1.5, second.ParkingTicket.Surge, "surge must be locked at entry once half full")
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &first.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(100), got.ParkingReceipt.Fees, "vehicle entered before the surge pays regular fees")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &second.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, 1.5, got.ParkingReceipt.Surge, "Surge must match")
+	assert.Equal(t, []parking.LineItem{
+		{Description: "Parking fee", Amount: 100},
+		{Description: "Surge x1.5", Amount: 50},
+	}, got.ParkingReceipt.LineItems, "LineItems must match")
+	assert.Equal(t, uint(150), got.ParkingReceipt.Fees, "surge locked at entry must apply though occupancy dropped")
+}
+
+func Test_calculatePrepaid(t *testing.T) {
+	now := internal.Now()
+	fee := parking.Fee{
+		Charge: parking.ChargeType_PerDay,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Prepaid: []parking.PrepaidRate{
+					{
+						DaysAhead: 7,
+						Rate:      70,
+					},
+					{
+						DaysAhead: 0,
+						Rate:      90,
+					},
+					{
+						DaysAhead: 30,
+						Rate:      50,
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		action  parking.Action
+		want    uint
+		wantErr bool
+	}{
+		{
+			name: "Car booked for 1 day and 1 hour, starting tomorrow. Fees: 180",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(24 * time.Hour),
+				Till:        now.Add(49 * time.Hour),
+			},
+			want:    180,
+			wantErr: false,
+		},
+		{
+			name: "Car booked for 3 days, starting in 10 days. Fees: 210",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(240 * time.Hour),
+				Till:        now.Add(312 * time.Hour),
+			},
+			want:    210,
+			wantErr: false,
+		},
+		{
+			name: "Car booked for 1 day, starting in 45 days. Fees: 50",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(1080 * time.Hour),
+				Till:        now.Add(1104 * time.Hour),
+			},
+			want:    50,
+			wantErr: false,
+		},
+		{
+			name: "Motorcycle has no prepaid rates",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_Motorcycle,
+				From:        now.Add(24 * time.Hour),
+				Till:        now.Add(48 * time.Hour),
+			},
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "window must end after it starts",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(48 * time.Hour),
+				Till:        now.Add(24 * time.Hour),
+			},
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculatePrepaid(tt.action, fee, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("calculatePrepaid() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("calculatePrepaid() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -663,31 +1052,3 @@ func Test_calculateFee(t *testing.T) {
 		})
 	}
 }
-
-func Test_getRecordKey(t *testing.T) {
-	type args struct {
-		ticketNo    string
-		vehicleType parking.VehicleType
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
-		{
-			name: "009Car/Suv",
-			args: args{
-				ticketNo:    "009",
-				vehicleType: parking.VehicleType_CarSuv,
-			},
-			want: "009Car/Suv",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getRecordKey(tt.args.ticketNo, tt.args.vehicleType); got != tt.want {
-				t.Errorf("getRecordKey() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sahaj/pkg/parking"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTampered is returned when the audit log was altered since written
+var ErrTampered = errors.New("audit log tampered")
+
+// Entry records an Action done on a Parking Lot and its result. Every entry carries the hash
+// of the one before it, so altering, removing or reordering entries breaks the chain, but for
+// entries removed from its end: those are only detected against a Head recorded before
+type Entry struct {
+	Seq               uint64              `json:"seq"`
+	At                time.Time           `json:"at"`
+	LotID             string              `json:"lotId"`
+	Action            parking.ActionType  `json:"action"`
+	VehicleType       parking.VehicleType `json:"vehicleType,omitempty"`
+	TicketNumber      string              `json:"ticketNumber,omitempty"` // presented or issued
+	ReceiptNumber     string              `json:"receiptNumber,omitempty"`
+	ReservationNumber string              `json:"reservationNumber,omitempty"`
+	AdjustmentNumber  string              `json:"adjustmentNumber,omitempty"`
+	Fees              uint                `json:"fees,omitempty"`
+	OperatorID        string              `json:"operatorId,omitempty"`
+	ErrorCode         parking.ErrorCode   `json:"errorCode,omitempty"`
+	Error             string              `json:"error,omitempty"`
+	PrevHash          string              `json:"prevHash"`
+	Hash              string              `json:"hash"`
+}
+
+// hash returns the hash of the entry, over all of it but its own hash
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends the hash-chained entries to its writer as JSON lines, it can be shared between Parking Lots
+type Log struct {
+	mu   sync.Mutex
+	w    io.Writer
+	seq  uint64
+	hash string // of the last entry
+	err  error  // first write failure
+}
+
+// NewLog starts a new chain of entries written to w
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// OpenLog verifies the audit log file at path, creating it if missing, and continues its chain
+func OpenLog(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	last, _, err := verify(f, Head{})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Log{w: f, seq: last.Seq, hash: last.Hash}, nil
+}
+
+// Close closes the writer of the log, if it can be closed
+func (l *Log) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Append chains the entry to the log, numbering it
+func (l *Log) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.Seq = l.seq + 1
+	entry.PrevHash = l.hash
+	hash, err := entry.hash()
+	if err != nil {
+		return l.fail(err)
+	}
+	entry.Hash = hash
+	if err := json.NewEncoder(l.w).Encode(entry); err != nil {
+		return l.fail(err)
+	}
+	l.seq, l.hash = entry.Seq, entry.Hash
+	return nil
+}
+
+// Err returns the first failure appending to the log, if any
+func (l *Log) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *Log) fail(err error) error {
+	if l.err == nil {
+		l.err = err
+	}
+	return err
+}
+
+// Head is the last entry of a chain, as recorded away from the log to detect entries removed from its end
+type Head struct {
+	Seq  uint64
+	Hash string
+}
+
+// ParseHead parses the head as printed by its String, seq:hash
+func ParseHead(val string) (Head, error) {
+	var head Head
+	seq, hash, ok := strings.Cut(val, ":")
+	if !ok || hash == "" {
+		return head, fmt.Errorf("head %q is not as seq:hash", val)
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return head, fmt.Errorf("head %q is not as seq:hash: %w", val, err)
+	}
+	return Head{Seq: n, Hash: hash}, nil
+}
+
+func (h Head) String() string {
+	return strconv.FormatUint(h.Seq, 10) + ":" + h.Hash
+}
+
+// Verify checks the chain of the audit log read from r, returning the number of entries verified
+func Verify(r io.Reader) (int, error) {
+	_, n, err := verify(r, Head{})
+	return n, err
+}
+
+// VerifyHead checks the chain of the audit log read from r as Verify, and that it still reaches
+// the head recorded before, returning the head the log ends at and the number of entries verified
+func VerifyHead(r io.Reader, head Head) (Head, int, error) {
+	last, n, err := verify(r, head)
+	return Head{Seq: last.Seq, Hash: last.Hash}, n, err
+}
+
+// verify checks the chain, reaching the head if its Seq is set
+func verify(r io.Reader, head Head) (Entry, int, error) {
+	var last Entry
+	n := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		n++
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return last, n - 1, fmt.Errorf("%w: line %d: %v", ErrTampered, n, err)
+		}
+		if entry.Seq != last.Seq+1 || entry.PrevHash != last.Hash {
+			return last, n - 1, fmt.Errorf("%w: line %d: entry %d does not follow entry %d", ErrTampered, n, entry.Seq, last.Seq)
+		}
+		hash, err := entry.hash()
+		if err != nil {
+			return last, n - 1, err
+		}
+		if hash != entry.Hash {
+			return last, n - 1, fmt.Errorf("%w: line %d: entry %d does not match its hash", ErrTampered, n, entry.Seq)
+		}
+		if entry.Seq == head.Seq && entry.Hash != head.Hash {
+			return last, n - 1, fmt.Errorf("%w: line %d: entry %d does not match the head", ErrTampered, n, entry.Seq)
+		}
+		last = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return last, n, err
+	}
+	if last.Seq < head.Seq {
+		return last, n, fmt.Errorf("%w: entries %d to %d of the head removed", ErrTampered, last.Seq+1, head.Seq)
+	}
+	return last, n, nil
+}
@@ -0,0 +1,188 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sahaj/internal"
+	"sahaj/internal/mall"
+	"sahaj/pkg/parking"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLot(t *testing.T, log *Log) *ParkingLot {
+	lot, err := mall.New("mall-1", parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 10}}}},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithClock(internal.Now))
+	assert.NoError(t, err, "mall.New must not fail")
+	audited := Wrap(lot, log)
+	audited.now = func() time.Time { return time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC) }
+	return audited
+}
+
+func TestParkingLot_Do(t *testing.T) {
+	var buf bytes.Buffer
+	lot := newLot(t, NewLog(&buf))
+
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &parked.ParkingTicket.TicketNumber})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3, "every Action must be recorded")
+	assert.Contains(t, lines[0], `"seq":1`, "entry must be numbered")
+	assert.Contains(t, lines[0], `"ticketNumber":"001"`, "issued ticket must be recorded")
+	assert.Contains(t, lines[1], `"errorCode":"NoSpace"`, "error must be recorded")
+	assert.Contains(t, lines[2], `"receiptNumber":"R-001"`, "receipt must be recorded")
+
+	n, err := Verify(strings.NewReader(buf.String()))
+	assert.NoError(t, err, "untouched log must verify")
+	assert.Equal(t, 3, n, "entries verified must match")
+}
+
+func TestVerify(t *testing.T) {
+	var buf bytes.Buffer
+	lot := newLot(t, NewLog(&buf))
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &parked.ParkingTicket.TicketNumber})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lines := strings.SplitAfter(strings.TrimSpace(buf.String()), "\n")
+
+	tests := []struct {
+		name   string
+		log    string
+		want   int
+		wantOK bool
+	}{
+		{
+			name:   "untouched log should verify",
+			log:    buf.String(),
+			want:   3,
+			wantOK: true,
+		},
+		{
+			name: "altered entry should not verify",
+			log:  lines[0] + strings.Replace(lines[1], `"action":"UnPark"`, `"action":"Park"`, 1) + lines[2],
+			want: 1,
+		},
+		{
+			name: "removed entry should not verify",
+			log:  lines[0] + lines[2],
+			want: 1,
+		},
+		{
+			name: "reordered entries should not verify",
+			log:  lines[1] + lines[0] + lines[2],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(strings.NewReader(tt.log))
+			if tt.wantOK {
+				assert.NoError(t, err, "Verify must not fail")
+			} else {
+				assert.ErrorIs(t, err, ErrTampered, "Verify must fail")
+			}
+			assert.Equal(t, tt.want, got, "entries verified must match")
+		})
+	}
+}
+
+func TestVerifyHead(t *testing.T) {
+	var buf bytes.Buffer
+	lot := newLot(t, NewLog(&buf))
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &parked.ParkingTicket.TicketNumber})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lines := strings.SplitAfter(strings.TrimSpace(buf.String()), "\n")
+	var second Entry
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second), "Unmarshal must not fail")
+
+	head, n, err := VerifyHead(strings.NewReader(buf.String()), Head{})
+	assert.NoError(t, err, "VerifyHead must not fail")
+	assert.Equal(t, 3, n, "entries verified must match")
+	assert.Equal(t, uint64(3), head.Seq, "head must be the last entry")
+	parsed, err := ParseHead(head.String())
+	assert.NoError(t, err, "ParseHead must not fail")
+	assert.Equal(t, head, parsed, "head must parse as printed")
+
+	tests := []struct {
+		name   string
+		log    string
+		head   Head
+		wantOK bool
+	}{
+		{
+			name:   "log reaching the head should verify",
+			log:    buf.String(),
+			head:   head,
+			wantOK: true,
+		},
+		{
+			name:   "log grown past the head should verify",
+			log:    buf.String(),
+			head:   Head{Seq: second.Seq, Hash: second.Hash},
+			wantOK: true,
+		},
+		{
+			name: "entries removed from the end should not verify against the head",
+			log:  lines[0] + lines[1],
+			head: head,
+		},
+		{
+			name: "log rewritten past the head should not verify",
+			log:  buf.String(),
+			head: Head{Seq: 3, Hash: "0000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := VerifyHead(strings.NewReader(tt.log), tt.head)
+			if tt.wantOK {
+				assert.NoError(t, err, "VerifyHead must not fail")
+			} else {
+				assert.ErrorIs(t, err, ErrTampered, "VerifyHead must fail")
+			}
+		})
+	}
+
+	// a chain truncated at its end is still valid on its own
+	n, err = Verify(strings.NewReader(lines[0] + lines[1]))
+	assert.NoError(t, err, "truncated log verifies without its head")
+	assert.Equal(t, 2, n, "entries verified must match")
+}
+
+func TestOpenLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := OpenLog(path)
+	assert.NoError(t, err, "OpenLog must not fail")
+	newLot(t, log).Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	assert.NoError(t, log.Close(), "Close must not fail")
+
+	// the chain continues once reopened
+	log, err = OpenLog(path)
+	assert.NoError(t, err, "OpenLog must not fail")
+	newLot(t, log).Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	assert.NoError(t, log.Close(), "Close must not fail")
+
+	f, err := os.Open(path)
+	assert.NoError(t, err, "Open must not fail")
+	defer f.Close()
+	n, err := Verify(f)
+	assert.NoError(t, err, "Verify must not fail")
+	assert.Equal(t, 2, n, "entries verified must match")
+
+	assert.NoError(t, os.WriteFile(path, []byte("{}\n"), 0644), "WriteFile must not fail")
+	_, err = OpenLog(path)
+	assert.ErrorIs(t, err, ErrTampered, "tampered log must not be opened")
+}
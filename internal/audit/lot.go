@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// ParkingLot records every Action done on the Parking Lot it wraps in an audit log
+type ParkingLot struct {
+	parking.ParkingLot
+	log *Log
+	now func() time.Time
+}
+
+// Wrap audits the Actions done on the Parking Lot in the log
+func Wrap(lot parking.ParkingLot, log *Log) *ParkingLot {
+	return &ParkingLot{ParkingLot: lot, log: log, now: time.Now}
+}
+
+// Unwrap returns the Parking Lot wrapped
+func (p *ParkingLot) Unwrap() parking.ParkingLot {
+	return p.ParkingLot
+}
+
+// Do does the Action on the wrapped Parking Lot then records it, the result is returned
+// as is even if recording failed, the Action having been done. The log keeps the failure
+func (p *ParkingLot) Do(action parking.Action) parking.Result {
+	res := p.ParkingLot.Do(action)
+	_ = p.log.Append(entry(p.GetID(), action, res, p.now()))
+	return res
+}
+
+func entry(lotID string, action parking.Action, res parking.Result, at time.Time) Entry {
+	e := Entry{
+		At:          at.UTC(),
+		LotID:       lotID,
+		Action:      action.ActionType,
+		VehicleType: action.VehicleType,
+		OperatorID:  action.OperatorID,
+	}
+	if action.TicketNumer != nil {
+		e.TicketNumber = *action.TicketNumer
+	}
+	if action.ReservationNumber != nil {
+		e.ReservationNumber = *action.ReservationNumber
+	}
+	if action.ReceiptNumber != nil {
+		e.ReceiptNumber = *action.ReceiptNumber
+	}
+	if res.ParkingTicket != nil {
+		e.TicketNumber = res.ParkingTicket.TicketNumber
+		e.Fees = res.ParkingTicket.Fees
+	}
+	if res.ParkingReceipt != nil {
+		e.ReceiptNumber = res.ParkingReceipt.ReceiptNumber
+		e.Fees = res.ParkingReceipt.Fees
+	}
+	if res.Reservation != nil {
+		e.ReservationNumber = res.Reservation.ReservationNumber
+	}
+	if res.Adjustment != nil {
+		e.AdjustmentNumber = res.Adjustment.AdjustmentNumber
+		e.Fees = res.Adjustment.Amount
+	}
+	if res.Err != nil {
+		e.ErrorCode = parking.CodeOf(res.Err)
+		e.Error = res.Err.Error()
+	}
+	return e
+}
+
+// Decorator audits the Actions done on Parking Lots in the log, for internal.WithDecorators
+func Decorator(log *Log) parking.Decorator {
+	return func(lot parking.ParkingLot) parking.ParkingLot {
+		return Wrap(lot, log)
+	}
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"fmt"
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// DefaultReservationRelease is how long a reservation holds its spot once its window starts,
+// before it is released as a no-show
+const DefaultReservationRelease = time.Hour
+
+// DefaultHoldAhead is how long before its reservation window starts a spot is held, for walk-ins
+// parking meanwhile not to take it
+const DefaultHoldAhead = time.Hour
+
+// DefaultEarlyArrival is how long before its reservation window starts a vehicle can arrive against it
+const DefaultEarlyArrival = 15 * time.Minute
+
+// Booking manages the reservations of a Parking Lot, the zero value is ready to use
+type Booking struct {
+	ReleaseAfter  time.Duration // no-show timeout, counted from start of the reservation window
+	EarlyArrival  time.Duration // vehicles can arrive this long before the window starts
+	HoldAhead     time.Duration // spots are held this long before the window starts, at least from early arrival
+	PadWidth      uint          // for printing reservation number
+	reservations  map[string]*parking.Reservation
+	reservationNo uint // tracks upcoming reservation
+}
+
+// Reserve books a spot for the vehicle over the time window of the action,
+// prepaid is the amount paid in advance for the window if any
+func (b *Booking) Reserve(action parking.Action, inventory map[parking.VehicleType]Inventory, now time.Time, prepaid uint) (*parking.Reservation, error) {
+	b.Release(now)
+	if err := b.validate(action, inventory, now, ""); err != nil {
+		return nil, err
+	}
+	b.reservationNo++
+	reservation := &parking.Reservation{
+		ReservationNumber: fmt.Sprintf(fmt.Sprintf("B-%%0%dd", b.PadWidth), b.reservationNo),
+		VehicleType:       action.VehicleType,
+		From:              action.From,
+		Till:              action.Till,
+		Status:            parking.ReservationStatus_Booked,
+		Prepaid:           prepaid,
+	}
+	if b.reservations == nil {
+		b.reservations = map[string]*parking.Reservation{}
+	}
+	b.reservations[reservation.ReservationNumber] = reservation
+	booked := *reservation
+	return &booked, nil
+}
+
+// Available checks a spot is free for the vehicle type over all of the time window of the action
+func (b *Booking) Available(action parking.Action, inventory map[parking.VehicleType]Inventory, now time.Time) error {
+	b.Release(now)
+	return b.validate(action, inventory, now, "")
+}
+
+// Pay records the receipt the prepaid amount of the reservation was collected with
+func (b *Booking) Pay(reservationNo string, receipt parking.Receipt) (parking.Reservation, bool) {
+	reservation, ok := b.reservations[reservationNo]
+	if !ok {
+		return parking.Reservation{}, false
+	}
+	reservation.ReceiptNumber = receipt.ReceiptNumber
+	reservation.PaymentID = receipt.PaymentID
+	return *reservation, true
+}
+
+// Booked returns the reservation if it is still open
+func (b *Booking) Booked(reservationNo *string, now time.Time) (parking.Reservation, error) {
+	b.Release(now)
+	reservation, err := b.lookup(reservationNo)
+	if err != nil {
+		return parking.Reservation{}, err
+	}
+	return *reservation, nil
+}
+
+// Cancel cancels an open reservation, releasing its spot
+func (b *Booking) Cancel(action parking.Action, now time.Time) (*parking.Reservation, error) {
+	b.Release(now)
+	reservation, err := b.lookup(action.ReservationNumber)
+	if err != nil {
+		return nil, err
+	}
+	reservation.Status = parking.ReservationStatus_Cancelled
+	cancelled := *reservation
+	return &cancelled, nil
+}
+
+// Modify moves an open reservation to the vehicle type & time window of the action
+func (b *Booking) Modify(action parking.Action, inventory map[parking.VehicleType]Inventory, now time.Time) (*parking.Reservation, error) {
+	b.Release(now)
+	reservation, err := b.lookup(action.ReservationNumber)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Prepaid > 0 {
+		return nil, parking.NewError(parking.ErrReservationClosed, "", "", "reservation "+reservation.ReservationNumber+" is prepaid")
+	}
+	if err := b.validate(action, inventory, now, reservation.ReservationNumber); err != nil {
+		return nil, err
+	}
+	reservation.VehicleType = action.VehicleType
+	reservation.From = action.From
+	reservation.Till = action.Till
+	modified := *reservation
+	return &modified, nil
+}
+
+// Open checks the reservation is open for the vehicle to arrive against, its window having started
+// or about to
+func (b *Booking) Open(reservationNo string, vehicleType parking.VehicleType, now time.Time) error {
+	b.Release(now)
+	reservation, err := b.lookup(&reservationNo)
+	if err != nil {
+		return err
+	}
+	if reservation.VehicleType != vehicleType {
+		return parking.NewError(parking.ErrVehicleMismatch, "", "", "reservation "+reservationNo+" is for "+reservation.VehicleType.String())
+	}
+	if opens := reservation.From.Add(-b.EarlyArrival); now.Before(opens) {
+		return parking.NewError(parking.ErrReservationWindow, "", "", "reservation "+reservationNo+" opens for arrival at "+opens.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Arrive marks the reservation as converted into a ticket
+func (b *Booking) Arrive(reservationNo string) {
+	if reservation, ok := b.reservations[reservationNo]; ok {
+		reservation.Status = parking.ReservationStatus_Arrived
+	}
+}
+
+// Get returns the reservation whatever its status
+func (b *Booking) Get(reservationNo string) (parking.Reservation, bool) {
+	reservation, ok := b.reservations[reservationNo]
+	if !ok {
+		return parking.Reservation{}, false
+	}
+	return *reservation, true
+}
+
+// Release marks reservations whose vehicle did not arrive in time as no-show
+func (b *Booking) Release(now time.Time) {
+	for _, reservation := range b.reservations {
+		if reservation.Status == parking.ReservationStatus_Booked && !now.Before(reservation.From.Add(b.ReleaseAfter)) {
+			reservation.Status = parking.ReservationStatus_NoShow
+		}
+	}
+}
+
+// Held returns number of spots of given type held for reservations which have started or start soon
+// but whose vehicle has not arrived yet, except is not counted
+func (b *Booking) Held(vehicleType parking.VehicleType, now time.Time, except string) uint {
+	ahead := b.HoldAhead
+	if ahead < b.EarlyArrival {
+		ahead = b.EarlyArrival
+	}
+	var held uint
+	for _, reservation := range b.reservations {
+		if reservation.ReservationNumber == except || reservation.VehicleType != vehicleType {
+			continue
+		}
+		if reservation.Status == parking.ReservationStatus_Booked &&
+			!now.Before(reservation.From.Add(-ahead)) && now.Before(reservation.From.Add(b.ReleaseAfter)) {
+			held++
+		}
+	}
+	return held
+}
+
+func (b *Booking) lookup(reservationNo *string) (*parking.Reservation, error) {
+	if reservationNo == nil {
+		return nil, parking.ErrReservationNotFound
+	}
+	reservation, ok := b.reservations[*reservationNo]
+	if !ok {
+		return nil, parking.NewError(parking.ErrReservationNotFound, "", "", "reservation "+*reservationNo)
+	}
+	if reservation.Status != parking.ReservationStatus_Booked {
+		return nil, parking.NewError(parking.ErrReservationClosed, "", "", "reservation "+*reservationNo+" is "+reservation.Status.String())
+	}
+	return reservation, nil
+}
+
+// validate checks the vehicle is allowed, the window is valid and a spot is free
+// for all of the window, except is not counted against the spots
+func (b *Booking) validate(action parking.Action, inventory map[parking.VehicleType]Inventory, now time.Time, except string) error {
+	inv, ok := inventory[action.VehicleType]
+	if !ok {
+		return parking.ErrVehicleNotAllowed
+	}
+	if action.From.IsZero() || !action.Till.After(action.From) || !action.Till.After(now) {
+		return parking.ErrReservationWindow
+	}
+	if booked := b.peak(action.VehicleType, action.From, action.Till, except); booked >= inv.Total {
+		return parking.NewError(parking.ErrNoSpace, "", "", fmt.Sprintf("%d of %d %s spots reserved", booked, inv.Total, action.VehicleType))
+	}
+	return nil
+}
+
+// peak returns the most reservations of given type overlapping at any instant of the window
+func (b *Booking) peak(vehicleType parking.VehicleType, from, till time.Time, except string) uint {
+	var overlapping []*parking.Reservation
+	for _, reservation := range b.reservations {
+		if reservation.ReservationNumber == except || reservation.VehicleType != vehicleType {
+			continue
+		}
+		if reservation.Status != parking.ReservationStatus_Booked && reservation.Status != parking.ReservationStatus_Arrived {
+			continue
+		}
+		if reservation.From.Before(till) && from.Before(reservation.Till) {
+			overlapping = append(overlapping, reservation)
+		}
+	}
+	// concurrency can only rise at the start of the window or of a reservation
+	instants := []time.Time{from}
+	for _, reservation := range overlapping {
+		if reservation.From.After(from) {
+			instants = append(instants, reservation.From)
+		}
+	}
+	var peak uint
+	for _, instant := range instants {
+		var concurrent uint
+		for _, reservation := range overlapping {
+			if !instant.Before(reservation.From) && instant.Before(reservation.Till) {
+				concurrent++
+			}
+		}
+		if concurrent > peak {
+			peak = concurrent
+		}
+	}
+	return peak
+}
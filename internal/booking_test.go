@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newBooking(now time.Time) *Booking {
+	b := &Booking{ReleaseAfter: time.Hour, EarlyArrival: 15 * time.Minute, HoldAhead: 30 * time.Minute, PadWidth: 3}
+	// B-001: Car/Suv from 2h till 26h, B-002: Car/Suv from 30h till 40h
+	b.Reserve(parking.Action{VehicleType: parking.VehicleType_CarSuv, From: now.Add(2 * time.Hour), Till: now.Add(26 * time.Hour)}, inventory, now, 0)
+	b.Reserve(parking.Action{VehicleType: parking.VehicleType_CarSuv, From: now.Add(30 * time.Hour), Till: now.Add(40 * time.Hour)}, inventory, now, 0)
+	return b
+}
+
+var inventory = map[parking.VehicleType]Inventory{
+	parking.VehicleType_CarSuv: {Total: 2},
+}
+
+func TestBooking_Reserve(t *testing.T) {
+	now := Now()
+	tests := []struct {
+		name    string
+		action  parking.Action
+		want    string
+		wantErr error
+	}{
+		{
+			name: "spot should be reserved while one is free for all of the window",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(1 * time.Hour),
+				Till:        now.Add(48 * time.Hour),
+			},
+			want: "B-003",
+		},
+		{
+			name: "vehicle not in inventory can not be reserved",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_Motorcycle,
+				From:        now.Add(1 * time.Hour),
+				Till:        now.Add(2 * time.Hour),
+			},
+			wantErr: parking.ErrVehicleNotAllowed,
+		},
+		{
+			name: "window must end after it starts",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(2 * time.Hour),
+				Till:        now.Add(1 * time.Hour),
+			},
+			wantErr: parking.ErrReservationWindow,
+		},
+		{
+			name: "window must not be over",
+			action: parking.Action{
+				VehicleType: parking.VehicleType_CarSuv,
+				From:        now.Add(-2 * time.Hour),
+				Till:        now.Add(-1 * time.Hour),
+			},
+			wantErr: parking.ErrReservationWindow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBooking(now)
+			got, err := b.Reserve(tt.action, inventory, now, 0)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got.ReservationNumber, "ReservationNumber must match")
+				assert.Equal(t, parking.ReservationStatus_Booked, got.Status, "Status must be Booked")
+			}
+		})
+	}
+}
+
+func TestBooking_Reserve_Full(t *testing.T) {
+	now := Now()
+	b := newBooking(now)
+	action := parking.Action{
+		VehicleType: parking.VehicleType_CarSuv,
+		From:        now.Add(20 * time.Hour),
+		Till:        now.Add(35 * time.Hour),
+	}
+	_, err := b.Reserve(action, inventory, now, 0)
+	assert.Nil(t, err, "second spot should be reserved")
+	_, err = b.Reserve(action, inventory, now, 0)
+	assert.ErrorIs(t, err, parking.ErrNoSpace, "both spots are reserved from 20h till 26h & 30h till 35h")
+
+	cancel := parking.Action{ReservationNumber: ToStringPtr("B-002")}
+	got, err := b.Cancel(cancel, now)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, parking.ReservationStatus_Cancelled, got.Status, "Status must be Cancelled")
+	_, err = b.Reserve(parking.Action{VehicleType: parking.VehicleType_CarSuv, From: now.Add(30 * time.Hour), Till: now.Add(35 * time.Hour)}, inventory, now, 0)
+	assert.Nil(t, err, "spot released by cancelled reservation should be reserved")
+
+	_, err = b.Cancel(cancel, now)
+	assert.ErrorIs(t, err, parking.ErrReservationClosed, "cancelled reservation can not be cancelled again")
+}
+
+func TestBooking_Modify(t *testing.T) {
+	now := Now()
+	tests := []struct {
+		name    string
+		action  parking.Action
+		wantErr error
+	}{
+		{
+			name: "reservation should move to a window free of others",
+			action: parking.Action{
+				ReservationNumber: ToStringPtr("B-001"),
+				VehicleType:       parking.VehicleType_CarSuv,
+				From:              now.Add(4 * time.Hour),
+				Till:              now.Add(34 * time.Hour),
+			},
+		},
+		{
+			name: "unknown reservation can not be modified",
+			action: parking.Action{
+				ReservationNumber: ToStringPtr("B-009"),
+				VehicleType:       parking.VehicleType_CarSuv,
+				From:              now.Add(4 * time.Hour),
+				Till:              now.Add(34 * time.Hour),
+			},
+			wantErr: parking.ErrReservationNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBooking(now)
+			got, err := b.Modify(tt.action, inventory, now)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.action.From, got.From, "From must match")
+				assert.Equal(t, tt.action.Till, got.Till, "Till must match")
+			}
+		})
+	}
+}
+
+func TestBooking_Held(t *testing.T) {
+	now := Now()
+	tests := []struct {
+		name   string
+		at     time.Duration
+		except string
+		want   uint
+	}{
+		{
+			name: "spot is not held well before the window starts",
+			at:   1 * time.Hour,
+			want: 0,
+		},
+		{
+			name: "spot is held shortly before the window starts",
+			at:   90 * time.Minute,
+			want: 1,
+		},
+		{
+			name: "spot is held once the window starts",
+			at:   2 * time.Hour,
+			want: 1,
+		},
+		{
+			name:   "spot is not held against its own reservation",
+			at:     2 * time.Hour,
+			except: "B-001",
+			want:   0,
+		},
+		{
+			name: "spot is released once the vehicle does not arrive in time",
+			at:   3 * time.Hour,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBooking(now)
+			got := b.Held(parking.VehicleType_CarSuv, now.Add(tt.at), tt.except)
+			assert.Equal(t, tt.want, got, "Held must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestBooking_Open(t *testing.T) {
+	now := Now()
+	tests := []struct {
+		name        string
+		at          time.Duration
+		vehicleType parking.VehicleType
+		wantErr     error
+	}{
+		{
+			name:        "vehicle can arrive against its reservation",
+			at:          2 * time.Hour,
+			vehicleType: parking.VehicleType_CarSuv,
+		},
+		{
+			name:        "vehicle can arrive a little before its window",
+			at:          105 * time.Minute,
+			vehicleType: parking.VehicleType_CarSuv,
+		},
+		{
+			name:        "vehicle can not arrive well before its window",
+			at:          time.Hour,
+			vehicleType: parking.VehicleType_CarSuv,
+			wantErr:     parking.ErrReservationWindow,
+		},
+		{
+			name:        "reservation is for another vehicle",
+			at:          2 * time.Hour,
+			vehicleType: parking.VehicleType_Motorcycle,
+			wantErr:     parking.ErrVehicleMismatch,
+		},
+		{
+			name:        "no-show reservation is released",
+			at:          3 * time.Hour,
+			vehicleType: parking.VehicleType_CarSuv,
+			wantErr:     parking.ErrReservationClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBooking(now)
+			err := b.Open("B-001", tt.vehicleType, now.Add(tt.at))
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+		})
+	}
+}
+
+func TestBooking_Pay(t *testing.T) {
+	now := Now()
+	b := newBooking(now)
+	window := parking.Action{VehicleType: parking.VehicleType_CarSuv, From: now.Add(3 * time.Hour), Till: now.Add(4 * time.Hour)}
+	assert.Nil(t, b.Available(window, inventory, now), "window must be available")
+	b.Reserve(window, inventory, now, 0)
+	assert.ErrorIs(t, b.Available(window, inventory, now), parking.ErrNoSpace, "window must not be available once both spots are booked")
+
+	got, ok := b.Pay("B-001", parking.Receipt{ReceiptNumber: "R-001", PaymentID: "CARD-1"})
+	assert.True(t, ok, "reservation must be found")
+	assert.Equal(t, "R-001", got.ReceiptNumber, "ReceiptNumber must match")
+
+	booked, err := b.Booked(ToStringPtr("B-001"), now)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, "CARD-1", booked.PaymentID, "PaymentID must be kept")
+
+	_, err = b.Booked(ToStringPtr("B-001"), now.Add(3*time.Hour))
+	assert.ErrorIs(t, err, parking.ErrReservationClosed, "no-show reservation must not be booked")
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"fmt"
+	"sahaj/pkg/parking"
+	"sort"
+	"time"
+)
+
+// Segment is a part of a stay billed at a single rate
+type Segment struct {
+	From, Till time.Time
+	Rate       parking.Rate
+}
+
+// SplitRates separates the base rates from the rates applicable on their calendar only
+func SplitRates(rates []parking.Rate) (base, calendar []parking.Rate) {
+	for _, rate := range rates {
+		if rate.When == nil {
+			base = append(base, rate)
+		} else {
+			calendar = append(calendar, rate)
+		}
+	}
+	return base, calendar
+}
+
+// Matches tells if the calendar applies at the instant, holidays are dates as 2006-01-02
+func Matches(when *parking.Calendar, holidays []string, t time.Time) bool {
+	if when == nil {
+		return true
+	}
+	if len(when.Weekdays) > 0 || when.Holidays {
+		day := false
+		for _, weekday := range when.Weekdays {
+			if time.Weekday(weekday) == t.Weekday() {
+				day = true
+			}
+		}
+		if when.Holidays {
+			for _, holiday := range holidays {
+				if holiday == t.Format("2006-01-02") {
+					day = true
+				}
+			}
+		}
+		if !day {
+			return false
+		}
+	}
+	start, end := window(when)
+	if start == end {
+		return true
+	}
+	now := sinceMidnight(t)
+	if start < end {
+		return start <= now && now < end
+	}
+	// window wraps around midnight
+	return now >= start || now < end
+}
+
+// RateAt returns the first of the calendar rates matching the instant, base otherwise
+func RateAt(calendar []parking.Rate, base parking.Rate, holidays []string, t time.Time) parking.Rate {
+	for _, rate := range calendar {
+		if Matches(rate.When, holidays, t) {
+			return rate
+		}
+	}
+	return base
+}
+
+// Split splits the stay where the rate matching it changes, i.e. at midnight
+// and at the start & end of the time windows of the calendar rates
+func Split(calendar []parking.Rate, base parking.Rate, holidays []string, entryTime, exitTime time.Time) []Segment {
+	boundaries := []time.Time{exitTime}
+	for day := midnight(entryTime); day.Before(exitTime); day = day.AddDate(0, 0, 1) {
+		instants := []time.Time{day}
+		for _, rate := range calendar {
+			start, end := window(rate.When)
+			instants = append(instants, day.Add(start), day.Add(end))
+		}
+		for _, instant := range instants {
+			if instant.After(entryTime) && instant.Before(exitTime) {
+				boundaries = append(boundaries, instant)
+			}
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	segments := []Segment{}
+	from := entryTime
+	for _, till := range boundaries {
+		if !till.After(from) {
+			continue
+		}
+		rate := RateAt(calendar, base, holidays, from)
+		if n := len(segments); n > 0 && segments[n-1].Rate == rate {
+			segments[n-1].Till = till
+		} else {
+			segments = append(segments, Segment{From: from, Till: till, Rate: rate})
+		}
+		from = till
+	}
+	return segments
+}
+
+// CheckCalendars validates the time windows of the calendar rates of the fee
+func CheckCalendars(fee parking.Fee) error {
+	for _, vehicle := range fee.Vehicles {
+		for _, rate := range vehicle.Rates {
+			if rate.When == nil {
+				continue
+			}
+			for _, val := range []string{rate.When.StartTime, rate.When.EndTime} {
+				if _, err := clock(val); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// window returns start & end of the time window of the calendar, since midnight.
+// The calendar is checked by NewParking, a time which does not parse is never reached
+func window(when *parking.Calendar) (time.Duration, time.Duration) {
+	start, _ := clock(when.StartTime)
+	end, _ := clock(when.EndTime)
+	return start, end
+}
+
+// clock parses time of day as 15:04, midnight if not set
+func clock(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", val)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time of day %q is not as 15:04", parking.ErrInvalidCalendar, val)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return t.Sub(midnight(t))
+}
@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	night = parking.Rate{
+		Rate: 20,
+		When: &parking.Calendar{StartTime: "22:00", EndTime: "06:00"},
+	}
+	weekend = parking.Rate{
+		Rate: 15,
+		When: &parking.Calendar{
+			Weekdays: []parking.Weekday{parking.Weekday(time.Saturday), parking.Weekday(time.Sunday)},
+			Holidays: true,
+		},
+	}
+	holidays = []string{"2022-07-04"}
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		when *parking.Calendar
+		at   time.Time
+		want bool
+	}{
+		{
+			name: "no calendar always matches",
+			when: nil,
+			at:   time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "window wrapping midnight matches before midnight",
+			when: night.When,
+			at:   time.Date(2022, 7, 1, 22, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "window wrapping midnight matches after midnight",
+			when: night.When,
+			at:   time.Date(2022, 7, 2, 5, 59, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "window does not match at its end",
+			when: night.When,
+			at:   time.Date(2022, 7, 2, 6, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "weekday set matches Saturday",
+			when: weekend.When,
+			at:   time.Date(2022, 7, 2, 12, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "weekday set does not match Friday",
+			when: weekend.When,
+			at:   time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			name: "holiday matches on a Monday",
+			when: weekend.When,
+			at:   time.Date(2022, 7, 4, 12, 0, 0, 0, time.UTC),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Matches(tt.when, holidays, tt.at)
+			assert.Equal(t, tt.want, got, "Matches must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	base := parking.Rate{Rate: 10}
+	friday := func(hour int) time.Time { return time.Date(2022, 7, 1, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name      string
+		entryTime time.Time
+		exitTime  time.Time
+		want      []Segment
+	}{
+		{
+			name:      "stay within a single window is not split",
+			entryTime: friday(10),
+			exitTime:  friday(14),
+			want: []Segment{
+				{From: friday(10), Till: friday(14), Rate: base},
+			},
+		},
+		{
+			name:      "Friday evening into Saturday is split at night and at the end of it",
+			entryTime: friday(20),
+			exitTime:  friday(34),
+			want: []Segment{
+				{From: friday(20), Till: friday(22), Rate: base},
+				{From: friday(22), Till: friday(30), Rate: night},
+				{From: friday(30), Till: friday(34), Rate: weekend},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split([]parking.Rate{night, weekend}, base, holidays, tt.entryTime, tt.exitTime)
+			assert.Equal(t, tt.want, got, "Segments must match")
+		})
+	}
+}
+
+func TestCheckCalendars(t *testing.T) {
+	tests := []struct {
+		name    string
+		when    *parking.Calendar
+		wantErr error
+	}{
+		{
+			name:    "window wrapping midnight is valid",
+			when:    night.When,
+			wantErr: nil,
+		},
+		{
+			name:    "calendar without a window is valid",
+			when:    weekend.When,
+			wantErr: nil,
+		},
+		{
+			name:    "start time not as 15:04 is invalid",
+			when:    &parking.Calendar{StartTime: "22.00", EndTime: "06:00"},
+			wantErr: parking.ErrInvalidCalendar,
+		},
+		{
+			name:    "end time out of range is invalid",
+			when:    &parking.Calendar{StartTime: "22:00", EndTime: "25:00"},
+			wantErr: parking.ErrInvalidCalendar,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee := parking.Fee{Vehicles: []parking.Vehicle{{Rates: []parking.Rate{{Rate: 10}, {Rate: 20, When: tt.when}}}}}
+			err := CheckCalendars(fee)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// Charge is what the stay of a vehicle costs on exit, before what was paid on entry & discounts are taken off
+type Charge struct {
+	EntryTime time.Time // from when the stay is charged
+	ExitTime  time.Time
+	Fees      uint
+	Prepaid   uint               // paid upfront for the reservation the vehicle arrived against, if any
+	LineItems []parking.LineItem // making up the fees
+	Calculate FeeFunc            // fees of part of the stay, for discounts shortening it
+}
+
+// ChargedFrom returns from when the stay of the vehicle is charged on exit, its entry or the expiry
+// of the pass it parked with, covered tells if the pass covers the start of the stay
+func (p Parking) ChargedFrom(rec *Record) (time.Time, bool) {
+	if validTill, ok := p.PassValidTill(rec.PassID); ok {
+		return validTill, true
+	}
+	return rec.EntryDateTime, false
+}
+
+// Charge calculates the fees of the stay from entryTime till exitTime, nothing if it ends before it starts,
+// with a line item of what the daily cap took off. The fee timer is told how long it took
+func (p Parking) Charge(calculate Calculator, action parking.Action, entryTime, exitTime time.Time) (Charge, error) {
+	charge := Charge{
+		EntryTime: entryTime,
+		ExitTime:  exitTime,
+		Calculate: func(entryTime, exitTime time.Time) (uint, error) {
+			return calculate(action, p.Fee, entryTime, exitTime)
+		},
+	}
+	var capped uint
+	if p.FeeTimer != nil {
+		start := time.Now()
+		defer func() { p.FeeTimer(p.ID, time.Since(start)) }()
+	}
+	if exitTime.After(entryTime) {
+		var err error
+		if charge.Fees, err = calculate(action, p.Fee, entryTime, exitTime); err != nil {
+			return charge, err
+		}
+		if capped, err = CappedFee(calculate, action, p.Fee, entryTime, exitTime, charge.Fees); err != nil {
+			return charge, err
+		}
+	}
+	charge.LineItems = []parking.LineItem{{Description: "Parking fee", Amount: int(charge.Fees + capped)}}
+	if capped > 0 {
+		charge.LineItems = append(charge.LineItems, parking.LineItem{Description: "Daily cap", Amount: -int(capped)})
+	}
+	return charge, nil
+}
+
+// Checkout takes what was paid on entry & the discounts of the action off the charge of the stay against
+// the ticket, then settles its receipt. The vehicle only exits once the receipt is settled, it is numbered then
+func (p Parking) Checkout(action parking.Action, records Records, receipts *Receipts, ticketNo string, charge Charge) (*parking.Receipt, error) {
+	rec, _ := records.Get(ticketNo)
+	fee, lineItems := charge.Fees, charge.LineItems
+	if paid := fee - Overstay(fee, rec.Paid); paid > 0 {
+		lineItems = append(lineItems, parking.LineItem{Description: "Paid on entry", Amount: -int(paid)})
+		fee -= paid
+	}
+	fee, discounts, err := p.ApplyDiscounts(action, charge.EntryTime, charge.ExitTime, fee, func(entryTime, exitTime time.Time) (uint, error) {
+		fees, err := charge.Calculate(entryTime, exitTime)
+		return Overstay(fees, rec.Paid), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	exitTime := charge.ExitTime
+	receipt := &parking.Receipt{
+		VehicleType:   rec.VehicleType,
+		Band:          Band(p.Fee, rec.VehicleType, exitTime.Sub(rec.EntryDateTime)),
+		EntryDateTime: rec.EntryDateTime,
+		ExitDateTime:  exitTime,
+		Fees:          fee,
+		Prepaid:       rec.Paid + charge.Prepaid,
+		PassID:        rec.PassID,
+		Surge:         rec.Surge,
+		PaymentStatus: parking.PaymentStatus_Pending,
+		LineItems:     append(lineItems, discounts...),
+	}
+	p.LogFee(ticketNo, *receipt)
+	paymentID, err := p.Settle(action, fee)
+	if err != nil {
+		return receipt, err
+	}
+	receipt.PaymentID = paymentID
+	receipt.IssuedAt = exitTime
+	records.Exit(ticketNo, exitTime)
+	p.RecordOccupancy(records, rec.VehicleType, exitTime)
+	return receipt, receipts.Issue(receipt)
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hourly charges every started hour of the stay at 20, at most the daily cap of the fee a day
+func hourly(action parking.Action, fee parking.Fee, entryTime, exitTime time.Time) (uint, error) {
+	if exitTime.Before(entryTime) {
+		return 0, parking.ErrExitTime
+	}
+	return CapDaily(fee.DailyCap, exitTime.Sub(entryTime), func(stay time.Duration) uint {
+		return uint(RoundUp(stay, 60).Hours()) * 20
+	}), nil
+}
+
+func TestParking_Charge(t *testing.T) {
+	entryTime := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		fee      parking.Fee
+		exitTime time.Time
+		want     uint
+		wantLine []parking.LineItem
+	}{
+		{
+			name:     "stay should be charged",
+			exitTime: entryTime.Add(150 * time.Minute),
+			want:     60,
+			wantLine: []parking.LineItem{{Description: "Parking fee", Amount: 60}},
+		},
+		{
+			name:     "daily cap should be shown",
+			fee:      parking.Fee{DailyCap: 100},
+			exitTime: entryTime.Add(6 * time.Hour),
+			want:     100,
+			wantLine: []parking.LineItem{{Description: "Parking fee", Amount: 120}, {Description: "Daily cap", Amount: -20}},
+		},
+		{
+			name:     "stay ending before it is charged from should be free",
+			exitTime: entryTime.Add(-time.Hour),
+			wantLine: []parking.LineItem{{Description: "Parking fee", Amount: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var timed []string
+			p := Parking{ID: "mall-1", Fee: tt.fee, FeeTimer: func(lotID string, took time.Duration) { timed = append(timed, lotID) }}
+			got, err := p.Charge(hourly, parking.Action{}, entryTime, tt.exitTime)
+			assert.Nil(t, err, "Err must be nil")
+			assert.Equal(t, tt.want, got.Fees, "Fees must match")
+			assert.Equal(t, tt.wantLine, got.LineItems, "LineItems must match")
+			assert.Equal(t, []string{"mall-1"}, timed, "fee calculation must be timed once")
+		})
+	}
+}
+
+func TestParking_Checkout(t *testing.T) {
+	entryTime := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	exitTime := entryTime.Add(150 * time.Minute)
+	tests := []struct {
+		name     string
+		paid     uint
+		gateway  payment.Gateway
+		want     uint
+		wantLine []parking.LineItem
+		wantErr  error
+	}{
+		{
+			name:     "receipt should be settled",
+			gateway:  payment.NewCash(),
+			want:     60,
+			wantLine: []parking.LineItem{{Description: "Parking fee", Amount: 60}},
+		},
+		{
+			name:     "amount paid on entry should be taken off",
+			paid:     20,
+			gateway:  payment.NewCash(),
+			want:     40,
+			wantLine: []parking.LineItem{{Description: "Parking fee", Amount: 60}, {Description: "Paid on entry", Amount: -20}},
+		},
+		{
+			name:    "vehicle should not exit when payment fails",
+			gateway: payment.NewFakeCard("card-1"),
+			wantErr: parking.ErrPaymentFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parking{ID: "mall-1", Gateway: tt.gateway}
+			rec := &Record{VehicleType: parking.VehicleType_CarSuv, EntryDateTime: entryTime, Paid: tt.paid}
+			records := NewRecords(map[string]*Record{"001": rec})
+			receipts := &Receipts{PadWidth: 3}
+			charge, err := p.Charge(hourly, parking.Action{}, entryTime, exitTime)
+			assert.Nil(t, err, "Err must be nil")
+
+			got, err := p.Checkout(parking.Action{PaymentSource: "card-1"}, records, receipts, "001", charge)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Equal(t, parking.PaymentStatus_Pending, got.PaymentStatus, "receipt must be pending")
+				assert.Nil(t, rec.ExitDateTime, "vehicle must not exit")
+				_, parked := records.Get("001")
+				assert.True(t, parked, "vehicle must stay parked")
+				return
+			}
+			assert.Equal(t, "R-001", got.ReceiptNumber, "ReceiptNumber must match")
+			assert.Equal(t, tt.want, got.Fees, "Fees must match")
+			assert.Equal(t, tt.paid, got.Prepaid, "Prepaid must match")
+			assert.Equal(t, tt.wantLine, got.LineItems, "LineItems must match")
+			assert.Equal(t, exitTime, *rec.ExitDateTime, "vehicle must exit")
+			assert.Equal(t, exitTime, got.IssuedAt, "receipt must be issued on exit")
+			_, err = records.Lookup("001", parking.VehicleType_CarSuv)
+			assert.IsType(t, &parking.TicketExitedError{}, err, "ticket must be exited")
+		})
+	}
+}
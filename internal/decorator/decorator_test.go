@@ -0,0 +1,58 @@
+package decorator
+
+import (
+	"bytes"
+	"log/slog"
+	"sahaj/internal"
+	"sahaj/internal/mall"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLot(t *testing.T) parking.ParkingLot {
+	lot, err := mall.New("mall-1", parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 10}}}},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithClock(internal.Now))
+	assert.NoError(t, err, "mall.New must not fail")
+	return lot
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	lot := Logging(internal.NewLogger(&buf, slog.LevelInfo, false))(newLot(t))
+
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2, "every Action must be logged")
+	assert.Contains(t, string(lines[0]), `level=INFO msg="action done" lot=mall-1 action=Park vehicle_type=Motorcycle`, "done Action must be logged")
+	assert.Contains(t, string(lines[0]), "ticket=001", "ticket must be logged")
+	assert.Contains(t, string(lines[1]), `level=WARN msg="action failed" lot=mall-1 action=Park vehicle_type=Motorcycle`, "failed Action must be logged")
+	assert.Contains(t, string(lines[1]), "code=NoSpace", "error must be logged")
+}
+
+func TestTiming(t *testing.T) {
+	timings := &Timings{}
+	lot := Timing(timings)(newLot(t))
+	lot.(*timing).since = func(time.Time) time.Duration { return time.Millisecond }
+
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: internal.ToStringPtr("001")})
+
+	got := timings.Timings()
+	assert.Equal(t, []ActionTiming{
+		{LotID: "mall-1", Action: parking.ActionType_Park, Count: 2, Failed: 1, Total: 2 * time.Millisecond, Max: time.Millisecond},
+		{LotID: "mall-1", Action: parking.ActionType_UnPark, Count: 1, Total: time.Millisecond, Max: time.Millisecond},
+	}, got, "Timings must match")
+	assert.Equal(t, time.Millisecond, got[0].Average(), "Average must match")
+}
@@ -0,0 +1,54 @@
+package decorator
+
+import (
+	"context"
+	"log/slog"
+	"sahaj/internal"
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// logging logs every Action done on the Parking Lot it wraps
+type logging struct {
+	parking.ParkingLot
+	logger *slog.Logger
+}
+
+// Logging logs every Action & its outcome to the logger, the default logger if nil:
+// done Actions at info, failed ones at warn
+func Logging(logger *slog.Logger) parking.Decorator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(lot parking.ParkingLot) parking.ParkingLot {
+		return &logging{ParkingLot: lot, logger: logger.With(internal.LogLot, lot.GetID())}
+	}
+}
+
+// Unwrap returns the Parking Lot wrapped
+func (l *logging) Unwrap() parking.ParkingLot {
+	return l.ParkingLot
+}
+
+func (l *logging) Do(action parking.Action) parking.Result {
+	start := time.Now()
+	res := l.ParkingLot.Do(action)
+	attrs := []slog.Attr{
+		slog.String("action", action.ActionType.String()),
+		slog.String(internal.LogVehicleType, action.VehicleType.String()),
+		slog.Duration("took", time.Since(start)),
+	}
+	switch {
+	case res.ParkingTicket != nil:
+		attrs = append(attrs, slog.String(internal.LogTicket, res.ParkingTicket.TicketNumber))
+	case action.TicketNumer != nil:
+		attrs = append(attrs, slog.String(internal.LogTicket, *action.TicketNumer))
+	}
+	if res.Err != nil {
+		attrs = append(attrs, slog.String("code", parking.CodeOf(res.Err).String()), slog.String("err", res.Err.Error()))
+		l.logger.LogAttrs(context.Background(), slog.LevelWarn, "action failed", attrs...)
+		return res
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, "action done", attrs...)
+	return res
+}
@@ -0,0 +1,103 @@
+package decorator
+
+import (
+	"sahaj/pkg/parking"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ActionTiming is how long an Action took on a Parking Lot, over all the times it was done
+type ActionTiming struct {
+	LotID  string             `json:"lotId"`
+	Action parking.ActionType `json:"action"`
+	Count  int                `json:"count"`
+	Failed int                `json:"failed"` // returned an error
+	Total  time.Duration      `json:"total"`
+	Max    time.Duration      `json:"max"`
+}
+
+// Average returns the average time the Action took
+func (t ActionTiming) Average() time.Duration {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Count)
+}
+
+// Timings collects how long Actions take, it can be shared between Parking Lots
+type Timings struct {
+	mu      sync.Mutex
+	timings map[timingKey]*ActionTiming
+}
+
+type timingKey struct {
+	lotID  string
+	action parking.ActionType
+}
+
+// Record adds an Action that took d
+func (t *Timings) Record(lotID string, action parking.ActionType, d time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timings == nil {
+		t.timings = map[timingKey]*ActionTiming{}
+	}
+	key := timingKey{lotID, action}
+	timing, ok := t.timings[key]
+	if !ok {
+		timing = &ActionTiming{LotID: lotID, Action: action}
+		t.timings[key] = timing
+	}
+	timing.Count++
+	if failed {
+		timing.Failed++
+	}
+	timing.Total += d
+	if d > timing.Max {
+		timing.Max = d
+	}
+}
+
+// Timings returns the timings recorded so far, by Parking Lot then Action
+func (t *Timings) Timings() []ActionTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timings := make([]ActionTiming, 0, len(t.timings))
+	for _, timing := range t.timings {
+		timings = append(timings, *timing)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].LotID != timings[j].LotID {
+			return timings[i].LotID < timings[j].LotID
+		}
+		return timings[i].Action < timings[j].Action
+	})
+	return timings
+}
+
+// timing times every Action done on the Parking Lot it wraps
+type timing struct {
+	parking.ParkingLot
+	timings *Timings
+	since   func(time.Time) time.Duration
+}
+
+// Timing records how long every Action takes in the timings
+func Timing(timings *Timings) parking.Decorator {
+	return func(lot parking.ParkingLot) parking.ParkingLot {
+		return &timing{ParkingLot: lot, timings: timings, since: time.Since}
+	}
+}
+
+// Unwrap returns the Parking Lot wrapped
+func (t *timing) Unwrap() parking.ParkingLot {
+	return t.ParkingLot
+}
+
+func (t *timing) Do(action parking.Action) parking.Result {
+	start := time.Now()
+	res := t.ParkingLot.Do(action)
+	t.timings.Record(t.GetID(), action.ActionType, t.since(start), res.Err != nil)
+	return res
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FeeFunc calculates the fees of a stay
+type FeeFunc func(entryTime, exitTime time.Time) (uint, error)
+
+// Discounts holds the discount rules by their codes, it can be shared between Parking Lots
+type Discounts struct {
+	mu        sync.Mutex
+	discounts map[string]parking.Discount
+}
+
+// NewDiscounts creates Discounts holding the given rules
+func NewDiscounts(discounts ...parking.Discount) *Discounts {
+	d := &Discounts{discounts: map[string]parking.Discount{}}
+	for _, discount := range discounts {
+		d.Add(discount)
+	}
+	return d
+}
+
+// Add adds a discount rule, replacing the one with same Code if any
+func (d *Discounts) Add(discount parking.Discount) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.discounts[discount.Code] = discount
+}
+
+// Apply applies the discounts of the codes to the fees of the stay, returning fees left to pay
+// and a negative line item per discount. Stackable discounts are combined while a non stackable
+// one only applies alone, whichever leaves the least to pay is applied
+func (d *Discounts) Apply(codes []string, entryTime, exitTime time.Time, fees uint, calculate FeeFunc) (uint, []parking.LineItem, error) {
+	d.mu.Lock()
+	var stackable []parking.Discount
+	var candidates [][]parking.Discount
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		discount, ok := d.discounts[code]
+		if !ok {
+			d.mu.Unlock()
+			return fees, nil, parking.NewError(parking.ErrDiscountNotFound, "", "", "code "+code)
+		}
+		if discount.Stackable {
+			stackable = append(stackable, discount)
+		} else {
+			candidates = append(candidates, []parking.Discount{discount})
+		}
+	}
+	d.mu.Unlock()
+	if len(stackable) > 0 {
+		candidates = append(candidates, stackable)
+	}
+
+	net, items := fees, []parking.LineItem(nil)
+	for _, candidate := range candidates {
+		candidateNet, candidateItems, err := applyDiscounts(candidate, entryTime, exitTime, fees, calculate)
+		if err != nil {
+			return fees, nil, err
+		}
+		if items == nil || candidateNet < net {
+			net, items = candidateNet, candidateItems
+		}
+	}
+	return net, items, nil
+}
+
+// applyDiscounts applies free minutes first as they shorten the stay, then percentages,
+// fixed amounts and finally caps on what is left to pay
+func applyDiscounts(discounts []parking.Discount, entryTime, exitTime time.Time, fees uint, calculate FeeFunc) (uint, []parking.LineItem, error) {
+	order := map[parking.DiscountType]int{
+		parking.DiscountType_FreeMinutes: 0,
+		parking.DiscountType_Percentage:  1,
+		parking.DiscountType_Fixed:       2,
+		parking.DiscountType_Cap:         3,
+	}
+	sorted := make([]parking.Discount, len(discounts))
+	copy(sorted, discounts)
+	sort.SliceStable(sorted, func(i, j int) bool { return order[sorted[i].Type] < order[sorted[j].Type] })
+
+	items := []parking.LineItem{}
+	for _, discount := range sorted {
+		if fees == 0 {
+			break
+		}
+		var amount uint
+		switch discount.Type {
+		case parking.DiscountType_FreeMinutes:
+			entryTime = entryTime.Add(time.Duration(discount.Value) * time.Minute)
+			var reduced uint
+			if exitTime.After(entryTime) {
+				var err error
+				reduced, err = calculate(entryTime, exitTime)
+				if err != nil {
+					return fees, nil, err
+				}
+			}
+			if reduced < fees {
+				amount = fees - reduced
+			}
+		case parking.DiscountType_Percentage:
+			amount = fees * discount.Value / 100
+		case parking.DiscountType_Fixed:
+			amount = discount.Value
+		case parking.DiscountType_Cap:
+			if fees > discount.Value {
+				amount = fees - discount.Value
+			}
+		}
+		if amount > fees {
+			amount = fees
+		}
+		if amount == 0 {
+			continue
+		}
+		fees -= amount
+		items = append(items, parking.LineItem{
+			Description: "Discount " + discount.Code,
+			Amount:      -int(amount),
+		})
+	}
+	return fees, items, nil
+}
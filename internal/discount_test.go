@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// perHour charges 20 for every started hour
+func perHour(entryTime, exitTime time.Time) (uint, error) {
+	minutes := uint(exitTime.Sub(entryTime).Minutes())
+	hours := minutes / 60
+	if minutes%60 > 0 {
+		hours++
+	}
+	return hours * 20, nil
+}
+
+func TestDiscounts_Apply(t *testing.T) {
+	discounts := NewDiscounts(
+		parking.Discount{Code: "SHOP2H", Type: parking.DiscountType_FreeMinutes, Value: 120, Stackable: true},
+		parking.Discount{Code: "TENOFF", Type: parking.DiscountType_Percentage, Value: 10, Stackable: true},
+		parking.Discount{Code: "FIVE", Type: parking.DiscountType_Fixed, Value: 5, Stackable: true},
+		parking.Discount{Code: "CAP30", Type: parking.DiscountType_Cap, Value: 30, Stackable: true},
+		parking.Discount{Code: "HALF", Type: parking.DiscountType_Percentage, Value: 50, Stackable: false},
+	)
+	entryTime := Now()
+	exitTime := entryTime.Add(5*time.Hour + 30*time.Minute)
+	tests := []struct {
+		name      string
+		codes     []string
+		want      uint
+		wantItems []parking.LineItem
+		wantErr   error
+	}{
+		{
+			name:  "first 2 hours free. Fees: 80",
+			codes: []string{"SHOP2H"},
+			want:  80,
+			wantItems: []parking.LineItem{
+				{Description: "Discount SHOP2H", Amount: -40},
+			},
+		},
+		{
+			name:  "free minutes apply before percentage, fixed amount & cap. Fees: 30",
+			codes: []string{"CAP30", "FIVE", "TENOFF", "SHOP2H"},
+			want:  30,
+			wantItems: []parking.LineItem{
+				{Description: "Discount SHOP2H", Amount: -40},
+				{Description: "Discount TENOFF", Amount: -8},
+				{Description: "Discount FIVE", Amount: -5},
+				{Description: "Discount CAP30", Amount: -37},
+			},
+		},
+		{
+			name:  "non stackable discount applies alone when it leaves less to pay. Fees: 60",
+			codes: []string{"TENOFF", "HALF"},
+			want:  60,
+			wantItems: []parking.LineItem{
+				{Description: "Discount HALF", Amount: -60},
+			},
+		},
+		{
+			name:  "stackable discounts apply when they leave less to pay. Fees: 30",
+			codes: []string{"HALF", "SHOP2H", "CAP30"},
+			want:  30,
+			wantItems: []parking.LineItem{
+				{Description: "Discount SHOP2H", Amount: -40},
+				{Description: "Discount CAP30", Amount: -50},
+			},
+		},
+		{
+			name:    "unknown code",
+			codes:   []string{"NOPE"},
+			want:    120,
+			wantErr: parking.ErrDiscountNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, _ := perHour(entryTime, exitTime)
+			got, items, err := discounts.Apply(tt.codes, entryTime, exitTime, fees, perHour)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			assert.Equal(t, tt.want, got, "Fees must match, want %v, got %v", tt.want, got)
+			assert.Equal(t, tt.wantItems, items, "LineItems must match")
+		})
+	}
+}
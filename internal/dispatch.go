@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sahaj/pkg/parking"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dispatcher delivers the domain events of Parking Lots to their subscribers
+type Dispatcher interface {
+	Dispatch(event parking.DomainEvent) error
+}
+
+// Sync notifies every subscriber in turn before returning
+type Sync struct {
+	subscribers []parking.Subscriber
+}
+
+// NewSync creates a Dispatcher notifying the subscribers in order
+func NewSync(subscribers ...parking.Subscriber) *Sync {
+	return &Sync{subscribers: subscribers}
+}
+
+// Dispatch notifies all subscribers, even past a failing one, returning the first failure
+func (s *Sync) Dispatch(event parking.DomainEvent) error {
+	var first error
+	for _, subscriber := range s.subscribers {
+		if err := subscriber.Notify(event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Async queues the events in a buffer, handing them over to the next Dispatcher in order
+// from its own goroutine. Failures of the next Dispatcher are dropped, wrap an Outbox
+// to have them retried
+type Async struct {
+	next   Dispatcher
+	queue  chan parking.DomainEvent
+	done   chan struct{}
+	closed sync.Once
+}
+
+// NewAsync starts dispatching to next, buffering up to size events before Dispatch blocks
+func NewAsync(next Dispatcher, size int) *Async {
+	a := &Async{
+		next:  next,
+		queue: make(chan parking.DomainEvent, size),
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(a.done)
+		for event := range a.queue {
+			_ = a.next.Dispatch(event)
+		}
+	}()
+	return a
+}
+
+// Dispatch queues the event, it must not be called once closed
+func (a *Async) Dispatch(event parking.DomainEvent) error {
+	a.queue <- event
+	return nil
+}
+
+// Close waits for the queued events to be dispatched
+func (a *Async) Close() {
+	a.closed.Do(func() { close(a.queue) })
+	<-a.done
+}
+
+// Emit dispatches the event of the Parking Lot, if it has a Dispatcher. The event is
+// given an ID and the time it occurred at if missing. Delivery failures do not fail
+// the Action the event is about, as it already happened
+func (p Parking) Emit(event parking.DomainEvent) {
+	if p.Dispatcher == nil {
+		return
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.At.IsZero() {
+		event.At = p.Now()
+	}
+	event.LotID = p.ID
+	_ = p.Dispatcher.Dispatch(event)
+}
+
+// EmitResult emits the domain event of the result of a park or an exit at the time,
+// and the vehicle type being full once the park took its last spot
+func (p Parking) EmitResult(action parking.Action, result parking.Result, records Records, at time.Time) {
+	// a vehicle is parked once ticketed & has exited once its receipt is settled,
+	// even if recording them failed afterwards
+	switch {
+	case action.ActionType == parking.ActionType_Park && result.ParkingTicket != nil:
+		p.Emit(parking.DomainEvent{
+			Type:        parking.DomainEventType_VehicleParked,
+			At:          result.ParkingTicket.EntryDateTime,
+			VehicleType: result.ParkingTicket.VehicleType,
+			Ticket:      result.ParkingTicket,
+			Receipt:     result.ParkingReceipt,
+		})
+		if vehicleType := result.ParkingTicket.VehicleType; records.Occupied(vehicleType) >= p.Inventory[vehicleType].Total {
+			p.Emit(parking.DomainEvent{
+				Type:        parking.DomainEventType_LotFull,
+				At:          result.ParkingTicket.EntryDateTime,
+				VehicleType: vehicleType,
+			})
+		}
+	case action.ActionType == parking.ActionType_Park:
+		p.Emit(parking.DomainEvent{
+			Type:        parking.DomainEventType_ParkRejected,
+			At:          at,
+			VehicleType: action.VehicleType,
+			Reason:      parking.CodeOf(result.Err),
+			Details:     result.Err.Error(),
+		})
+	case action.ActionType == parking.ActionType_UnPark && result.ParkingReceipt != nil && result.ParkingReceipt.PaymentStatus == parking.PaymentStatus_Settled:
+		p.Emit(parking.DomainEvent{
+			Type:        parking.DomainEventType_VehicleExited,
+			At:          result.ParkingReceipt.ExitDateTime,
+			VehicleType: result.ParkingReceipt.VehicleType,
+			Receipt:     result.ParkingReceipt,
+		})
+	}
+}
+
+// EmitOverstays emits the vehicles parked longer than the maximum stay, once per vehicle
+func (p Parking) EmitOverstays(records Records, now time.Time) {
+	if p.MaxStay == 0 || p.Dispatcher == nil {
+		return
+	}
+	var overstayed []string
+	for ticketNo, rec := range records.Parked() {
+		if !rec.Overstayed && now.Sub(rec.EntryDateTime) > p.MaxStay {
+			overstayed = append(overstayed, ticketNo)
+		}
+	}
+	sort.Strings(overstayed)
+	for _, ticketNo := range overstayed {
+		rec, _ := records.Get(ticketNo)
+		rec.Overstayed = true
+		ticket := rec.Ticket(ticketNo)
+		p.Emit(parking.DomainEvent{
+			Type:        parking.DomainEventType_VehicleOverstayed,
+			At:          now,
+			VehicleType: rec.VehicleType,
+			Ticket:      &ticket,
+		})
+	}
+}
+
+// SetFee changes the tariff of the Parking Lot, emitting the change
+func (p *Parking) SetFee(fee parking.Fee, at time.Time) {
+	p.Fee = fee
+	p.Emit(parking.DomainEvent{
+		Type: parking.DomainEventType_TariffChanged,
+		At:   at,
+		Fee:  &fee,
+	})
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
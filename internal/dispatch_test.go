@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"errors"
+	"sahaj/pkg/parking"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// subscriber records the events it is notified of, failing the first fail of them
+type subscriber struct {
+	mu     sync.Mutex
+	events []parking.DomainEvent
+	fail   int
+}
+
+func (s *subscriber) Notify(event parking.DomainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestSync_Dispatch(t *testing.T) {
+	failing, ok := &subscriber{fail: 1}, &subscriber{}
+	sync := NewSync(failing, ok)
+
+	err := sync.Dispatch(parking.DomainEvent{ID: "1"})
+	assert.Error(t, err, "failure of a subscriber must be returned")
+	assert.Len(t, ok.events, 1, "subscribers past a failing one must be notified")
+	assert.NoError(t, sync.Dispatch(parking.DomainEvent{ID: "2"}), "Dispatch must not fail")
+	assert.Len(t, failing.events, 1, "subscriber must be notified once recovered")
+}
+
+func TestAsync_Dispatch(t *testing.T) {
+	s := &subscriber{}
+	async := NewAsync(NewSync(s), 1)
+	for _, id := range []string{"1", "2", "3"} {
+		assert.NoError(t, async.Dispatch(parking.DomainEvent{ID: id}), "Dispatch must not fail")
+	}
+	async.Close()
+	var ids []string
+	for _, event := range s.events {
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids, "events must be dispatched in order")
+}
+
+func TestParking_EmitResult(t *testing.T) {
+	now := Now()
+	ticket := &parking.Ticket{TicketNumber: "001", VehicleType: parking.VehicleType_CarSuv, EntryDateTime: now}
+	tests := []struct {
+		name    string
+		action  parking.Action
+		result  parking.Result
+		records Records
+		want    []parking.DomainEvent
+	}{
+		{
+			name:   "ticketed vehicle should be parked",
+			action: parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{ParkingTicket: ticket},
+			want: []parking.DomainEvent{
+				{Type: parking.DomainEventType_VehicleParked, LotID: "mall-1", At: now, VehicleType: parking.VehicleType_CarSuv, Ticket: ticket},
+			},
+		},
+		{
+			name:    "vehicle taking the last spot should fill the lot",
+			action:  parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv},
+			result:  parking.Result{ParkingTicket: ticket},
+			records: NewRecords(map[string]*Record{"001": {VehicleType: parking.VehicleType_CarSuv}, "002": {VehicleType: parking.VehicleType_CarSuv}}),
+			want: []parking.DomainEvent{
+				{Type: parking.DomainEventType_VehicleParked, LotID: "mall-1", At: now, VehicleType: parking.VehicleType_CarSuv, Ticket: ticket},
+				{Type: parking.DomainEventType_LotFull, LotID: "mall-1", At: now, VehicleType: parking.VehicleType_CarSuv},
+			},
+		},
+		{
+			name:   "park failing should be rejected with its reason",
+			action: parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{Err: parking.NewError(parking.ErrNoSpace, "mall-1", "", "")},
+			want: []parking.DomainEvent{
+				{Type: parking.DomainEventType_ParkRejected, LotID: "mall-1", At: now, VehicleType: parking.VehicleType_CarSuv, Reason: parking.ErrorCode_NoSpace, Details: "[NoSpace] lot mall-1: no space available"},
+			},
+		},
+		{
+			name:   "settled receipt should exit the vehicle",
+			action: parking.Action{ActionType: parking.ActionType_UnPark},
+			result: parking.Result{ParkingReceipt: &parking.Receipt{VehicleType: parking.VehicleType_CarSuv, ExitDateTime: now, PaymentStatus: parking.PaymentStatus_Settled}},
+			want: []parking.DomainEvent{
+				{Type: parking.DomainEventType_VehicleExited, LotID: "mall-1", At: now, VehicleType: parking.VehicleType_CarSuv, Receipt: &parking.Receipt{VehicleType: parking.VehicleType_CarSuv, ExitDateTime: now, PaymentStatus: parking.PaymentStatus_Settled}},
+			},
+		},
+		{
+			name:   "pending receipt should not exit the vehicle",
+			action: parking.Action{ActionType: parking.ActionType_UnPark},
+			result: parking.Result{ParkingReceipt: &parking.Receipt{PaymentStatus: parking.PaymentStatus_Pending}, Err: parking.ErrPaymentFailed},
+		},
+		{
+			name:   "other actions should emit nothing",
+			action: parking.Action{ActionType: parking.ActionType_Reserve},
+			result: parking.Result{Reservation: &parking.Reservation{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &subscriber{}
+			p := Parking{ID: "mall-1", Dispatcher: NewSync(s), Inventory: map[parking.VehicleType]Inventory{parking.VehicleType_CarSuv: {Total: 2}}}
+			p.EmitResult(tt.action, tt.result, tt.records, now)
+			for i := range s.events {
+				assert.NotEmpty(t, s.events[i].ID, "ID must be set")
+				s.events[i].ID = ""
+			}
+			assert.Equal(t, tt.want, s.events, "events must match")
+		})
+	}
+}
+
+func TestParking_EmitOverstays(t *testing.T) {
+	now := Now()
+	exited := now
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_CarSuv, EntryDateTime: now.Add(-5 * time.Hour)},
+		"002": {VehicleType: parking.VehicleType_CarSuv, EntryDateTime: now.Add(-time.Hour)},
+		"003": {VehicleType: parking.VehicleType_CarSuv, EntryDateTime: now.Add(-5 * time.Hour), ExitDateTime: &exited},
+	})
+	s := &subscriber{}
+	p := Parking{ID: "mall-1", Dispatcher: NewSync(s), MaxStay: 4 * time.Hour}
+
+	p.EmitOverstays(records, now)
+	p.EmitOverstays(records, now.Add(time.Minute))
+	assert.Len(t, s.events, 1, "overstay must be emitted once, for active vehicles only")
+	assert.Equal(t, parking.DomainEventType_VehicleOverstayed, s.events[0].Type, "Type must match")
+	assert.Equal(t, "001", s.events[0].Ticket.TicketNumber, "TicketNumber must match")
+	rec, _ := records.Get("001")
+	assert.True(t, rec.Overstayed, "record must be marked")
+}
+
+func TestParking_SetFee(t *testing.T) {
+	s := &subscriber{}
+	p := Parking{ID: "mall-1", Dispatcher: NewSync(s)}
+	fee := parking.Fee{Charge: parking.ChargeType_PerHour}
+	p.SetFee(fee, time.Time{})
+	assert.Equal(t, fee, p.Fee, "Fee must be changed")
+	assert.Len(t, s.events, 1, "change must be emitted")
+	assert.Equal(t, parking.DomainEventType_TariffChanged, s.events[0].Type, "Type must match")
+	assert.Equal(t, &fee, s.events[0].Fee, "Fee must match")
+	assert.False(t, s.events[0].At.IsZero(), "At must be set")
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sync"
+	"time"
+)
+
+// Events holds the events scheduled at a Parking Lot
+type Events struct {
+	mu     sync.Mutex
+	events []parking.Event
+}
+
+// NewEvents creates Events holding the given events
+func NewEvents(events ...parking.Event) (*Events, error) {
+	e := &Events{}
+	for _, event := range events {
+		if err := e.Schedule(event); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Schedule adds an event, its window must not overlap the window of another event
+func (e *Events) Schedule(event parking.Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if event.Name == "" || !event.End.After(event.Start) {
+		return parking.NewError(parking.ErrEventWindow, "", "", "event "+event.Name)
+	}
+	for _, scheduled := range e.events {
+		if scheduled.Start.Before(event.End) && event.Start.Before(scheduled.End) {
+			return parking.NewError(parking.ErrEventWindow, "", "", "event "+event.Name+" overlaps "+scheduled.Name)
+		}
+	}
+	e.events = append(e.events, event)
+	return nil
+}
+
+// At returns the event on at the time, if any
+func (e *Events) At(t time.Time) (parking.Event, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, event := range e.events {
+		if !t.Before(event.Start) && t.Before(event.End) {
+			return event, true
+		}
+	}
+	return parking.Event{}, false
+}
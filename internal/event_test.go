@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents_Schedule(t *testing.T) {
+	now := Now()
+	tests := []struct {
+		name    string
+		event   parking.Event
+		wantErr error
+	}{
+		{
+			name:  "event should be scheduled after the other ends",
+			event: parking.Event{Name: "Concert", Start: now.Add(4 * time.Hour), End: now.Add(6 * time.Hour)},
+		},
+		{
+			name:    "event must not overlap another",
+			event:   parking.Event{Name: "Concert", Start: now.Add(3 * time.Hour), End: now.Add(6 * time.Hour)},
+			wantErr: parking.ErrEventWindow,
+		},
+		{
+			name:    "event must end after it starts",
+			event:   parking.Event{Name: "Concert", Start: now.Add(6 * time.Hour), End: now.Add(5 * time.Hour)},
+			wantErr: parking.ErrEventWindow,
+		},
+		{
+			name:    "event must have a name",
+			event:   parking.Event{Start: now.Add(5 * time.Hour), End: now.Add(6 * time.Hour)},
+			wantErr: parking.ErrEventWindow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEvents(parking.Event{Name: "Final", Start: now, End: now.Add(4 * time.Hour)})
+			assert.Nil(t, err, "Err must be nil")
+			err = e.Schedule(tt.event)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+		})
+	}
+}
+
+func TestEvents_At(t *testing.T) {
+	now := Now()
+	e, err := NewEvents(parking.Event{Name: "Final", Start: now, End: now.Add(4 * time.Hour)})
+	assert.Nil(t, err, "Err must be nil")
+	tests := []struct {
+		name string
+		at   time.Duration
+		want bool
+	}{
+		{name: "no event before it starts", at: -time.Minute, want: false},
+		{name: "event is on once it starts", at: 0, want: true},
+		{name: "no event once it ends", at: 4 * time.Hour, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := e.At(now.Add(tt.at))
+			assert.Equal(t, tt.want, got, "event must be on: %v", tt.want)
+		})
+	}
+}
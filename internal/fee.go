@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// Calculator calculates the fees of a stay of the vehicle of the action as per the tariff,
+// each model of Parking Lot has its own
+type Calculator func(action parking.Action, fee parking.Fee, entryTime, exitTime time.Time) (uint, error)
+
+// RoundUp rounds the stay up to a multiple of unit minutes, as is if unit is 0
+func RoundUp(stay time.Duration, unit uint) time.Duration {
+	if unit == 0 {
+		return stay
+	}
+	step := time.Duration(unit) * time.Minute
+	if rem := stay % step; rem > 0 {
+		stay += step - rem
+	}
+	return stay
+}
+
+// CapDaily charges every 24 hours of the stay, counted from entry, at most cap,
+// charge returns the fees of a stay so far; the stay is charged as is if cap is 0
+func CapDaily(cap uint, stay time.Duration, charge func(stay time.Duration) uint) uint {
+	if cap == 0 {
+		return charge(stay)
+	}
+	var fees, charged uint
+	for from := time.Duration(0); from < stay; from += 24 * time.Hour {
+		till := from + 24*time.Hour
+		if till > stay {
+			till = stay
+		}
+		total := charge(till)
+		day := total - charged
+		charged = total
+		if day > cap {
+			day = cap
+		}
+		fees += day
+	}
+	return fees
+}
+
+// CappedFee returns how much the daily cap of the fee took off the fees of the stay
+func CappedFee(calculate Calculator, action parking.Action, fee parking.Fee, entryTime, exitTime time.Time, fees uint) (uint, error) {
+	if fee.DailyCap == 0 {
+		return 0, nil
+	}
+	fee.DailyCap = 0
+	uncapped, err := calculate(action, fee, entryTime, exitTime)
+	if err != nil || uncapped < fees {
+		return 0, err
+	}
+	return uncapped - fees, nil
+}
+
+// ApplyLimits applies the grace period & minimum charge of the fee to the fees of the stay,
+// stays within the grace period are free while longer ones pay at least the minimum charge
+func ApplyLimits(fee parking.Fee, stay time.Duration, fees uint) uint {
+	if stay <= time.Duration(fee.GraceMinutes)*time.Minute {
+		return 0
+	}
+	if fees < fee.MinimumCharge {
+		return fee.MinimumCharge
+	}
+	return fees
+}
+
+// EntryStay returns the stay paid for on entry, the first base rate interval of the vehicle or
+// an hour if it is open ended, short of its very end so the next interval is not charged
+func EntryStay(fee parking.Fee, vehicleType parking.VehicleType) time.Duration {
+	for _, vehicle := range fee.Vehicles {
+		if vehicle.Kind != vehicleType {
+			continue
+		}
+		base, _ := SplitRates(vehicle.Rates)
+		var first *parking.Rate
+		for i := range base {
+			if first == nil || base[i].From < first.From {
+				first = &base[i]
+			}
+		}
+		if first != nil && first.Till > first.From {
+			return time.Duration(first.Till)*time.Hour - time.Nanosecond
+		}
+	}
+	return time.Hour - time.Nanosecond
+}
+
+// Overstay returns the fees left to pay once the amount paid on entry is taken off
+func Overstay(fees, paid uint) uint {
+	if fees > paid {
+		return fees - paid
+	}
+	return 0
+}
+
+// SurgeAt returns the multiplier of the highest surge threshold of the vehicle the occupancy has reached,
+// 0 if none
+func SurgeAt(fee parking.Fee, vehicleType parking.VehicleType, occupancy float64) float64 {
+	var surge parking.Surge
+	for _, vehicle := range fee.Vehicles {
+		if vehicle.Kind != vehicleType {
+			continue
+		}
+		for _, s := range vehicle.Surge {
+			if occupancy >= s.Occupancy && s.Occupancy >= surge.Occupancy {
+				surge = s
+			}
+		}
+	}
+	return surge.Multiplier
+}
+
+// Surcharge returns what the surge multiplier adds to the fees, rounded to the nearest unit
+func Surcharge(fees uint, multiplier float64) uint {
+	if multiplier <= 1 {
+		return 0
+	}
+	return uint(math.Round(float64(fees)*multiplier)) - fees
+}
+
+// Band returns the label of the base rate interval of the vehicle the stay falls in, like "4-12h" or "12h+"
+func Band(fee parking.Fee, vehicleType parking.VehicleType, stay time.Duration) string {
+	var band *parking.Rate
+	for _, vehicle := range fee.Vehicles {
+		if vehicle.Kind != vehicleType {
+			continue
+		}
+		base, _ := SplitRates(vehicle.Rates)
+		for i := range base {
+			if time.Duration(base[i].From)*time.Hour <= stay && (band == nil || base[i].From > band.From) {
+				band = &base[i]
+			}
+		}
+	}
+	if band == nil {
+		return ""
+	}
+	if band.Till <= band.From {
+		return fmt.Sprintf("%dh+", band.From)
+	}
+	return fmt.Sprintf("%d-%dh", band.From, band.Till)
+}
@@ -0,0 +1,264 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundUp(t *testing.T) {
+	tests := []struct {
+		name string
+		stay time.Duration
+		unit uint
+		want time.Duration
+	}{
+		{
+			name: "no rounding unit keeps the stay as is",
+			stay: 61*time.Minute + 30*time.Second,
+			unit: 0,
+			want: 61*time.Minute + 30*time.Second,
+		},
+		{
+			name: "stay should be rounded up to the started unit",
+			stay: 61 * time.Minute,
+			unit: 30,
+			want: 90 * time.Minute,
+		},
+		{
+			name: "stay of whole units is not rounded",
+			stay: 60 * time.Minute,
+			unit: 15,
+			want: 60 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RoundUp(tt.stay, tt.unit)
+			assert.Equal(t, tt.want, got, "RoundUp must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestCapDaily(t *testing.T) {
+	// 30 on entry, then 10 an hour
+	charge := func(stay time.Duration) uint {
+		return 30 + 10*uint(stay.Hours())
+	}
+	tests := []struct {
+		name string
+		cap  uint
+		stay time.Duration
+		want uint
+	}{
+		{
+			name: "no cap charges the stay as is",
+			cap:  0,
+			stay: 30 * time.Hour,
+			want: 330,
+		},
+		{
+			name: "day under the cap is charged as is",
+			cap:  200,
+			stay: 5 * time.Hour,
+			want: 80,
+		},
+		{
+			name: "every day is capped on its own",
+			cap:  200,
+			stay: 54 * time.Hour,
+			want: 200 + 200 + 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CapDaily(tt.cap, tt.stay, charge)
+			assert.Equal(t, tt.want, got, "CapDaily must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestApplyLimits(t *testing.T) {
+	fee := parking.Fee{GraceMinutes: 10, MinimumCharge: 25}
+	tests := []struct {
+		name string
+		stay time.Duration
+		fees uint
+		want uint
+	}{
+		{
+			name: "stay within grace period is free",
+			stay: 10 * time.Minute,
+			fees: 20,
+			want: 0,
+		},
+		{
+			name: "stay past grace period pays at least the minimum charge",
+			stay: 11 * time.Minute,
+			fees: 20,
+			want: 25,
+		},
+		{
+			name: "fees above the minimum charge are kept",
+			stay: 3 * time.Hour,
+			fees: 60,
+			want: 60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyLimits(fee, tt.stay, tt.fees)
+			assert.Equal(t, tt.want, got, "ApplyLimits must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestEntryStay(t *testing.T) {
+	fee := parking.Fee{
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_Motorcycle,
+				Rates: []parking.Rate{
+					{From: 4, Till: 12, Rate: 60},
+					{From: 0, Till: 4, Rate: 30},
+					{From: 0, Till: 6, Rate: 50, When: &parking.Calendar{Holidays: true}},
+				},
+			},
+			{
+				Kind:  parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{{Rate: 20}},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		vehicleType parking.VehicleType
+		want        time.Duration
+	}{
+		{
+			name:        "first base rate interval is paid on entry",
+			vehicleType: parking.VehicleType_Motorcycle,
+			want:        4*time.Hour - time.Nanosecond,
+		},
+		{
+			name:        "open ended rate pays an hour on entry",
+			vehicleType: parking.VehicleType_CarSuv,
+			want:        time.Hour - time.Nanosecond,
+		},
+		{
+			name:        "vehicle without rates pays an hour on entry",
+			vehicleType: parking.VehicleType_BusTruck,
+			want:        time.Hour - time.Nanosecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EntryStay(fee, tt.vehicleType)
+			assert.Equal(t, tt.want, got, "EntryStay must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestSurgeAt(t *testing.T) {
+	fee := parking.Fee{
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Surge: []parking.Surge{
+					{Occupancy: 0.95, Multiplier: 2},
+					{Occupancy: 0.9, Multiplier: 1.5},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		vehicleType parking.VehicleType
+		occupancy   float64
+		want        float64
+	}{
+		{
+			name:        "no surge below the lowest threshold",
+			vehicleType: parking.VehicleType_CarSuv,
+			occupancy:   0.89,
+			want:        0,
+		},
+		{
+			name:        "surge once the threshold is reached",
+			vehicleType: parking.VehicleType_CarSuv,
+			occupancy:   0.9,
+			want:        1.5,
+		},
+		{
+			name:        "highest threshold reached wins",
+			vehicleType: parking.VehicleType_CarSuv,
+			occupancy:   0.97,
+			want:        2,
+		},
+		{
+			name:        "vehicle without surge policy",
+			vehicleType: parking.VehicleType_Motorcycle,
+			occupancy:   1,
+			want:        0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SurgeAt(fee, tt.vehicleType, tt.occupancy)
+			assert.Equal(t, tt.want, got, "SurgeAt must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestSurcharge(t *testing.T) {
+	tests := []struct {
+		name       string
+		fees       uint
+		multiplier float64
+		want       uint
+	}{
+		{name: "no surcharge without surge", fees: 100, multiplier: 0, want: 0},
+		{name: "surcharge is what the multiplier adds", fees: 100, multiplier: 1.5, want: 50},
+		{name: "surcharge is rounded to the nearest unit", fees: 35, multiplier: 1.1, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Surcharge(tt.fees, tt.multiplier)
+			assert.Equal(t, tt.want, got, "Surcharge must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestBand(t *testing.T) {
+	fee := parking.Fee{
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_Motorcycle,
+				Rates: []parking.Rate{
+					{From: 4, Till: 12, Rate: 60},
+					{From: 0, Till: 4, Rate: 30},
+					{From: 12, Till: 0, Rate: 100},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		vehicleType parking.VehicleType
+		stay        time.Duration
+		want        string
+	}{
+		{name: "stay within the first interval", vehicleType: parking.VehicleType_Motorcycle, stay: 3 * time.Hour, want: "0-4h"},
+		{name: "stay from the start of an interval", vehicleType: parking.VehicleType_Motorcycle, stay: 4 * time.Hour, want: "4-12h"},
+		{name: "stay in the open ended interval", vehicleType: parking.VehicleType_Motorcycle, stay: 30 * time.Hour, want: "12h+"},
+		{name: "vehicle without rates", vehicleType: parking.VehicleType_CarSuv, stay: time.Hour, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Band(fee, tt.vehicleType, tt.stay)
+			assert.Equal(t, tt.want, got, "Band must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sahaj/pkg/parking"
+	"sync"
+)
+
+// Entry is a line of the Ledger, a settled receipt or an adjustment of one
+type Entry struct {
+	LotID      string              `json:"lotId"`
+	Receipt    *parking.Receipt    `json:"receipt,omitempty"`
+	Adjustment *parking.Adjustment `json:"adjustment,omitempty"`
+}
+
+// Ledger keeps the history of receipts & adjustments of Parking Lots, it can be shared between them.
+// Entries are appended to its writer as JSON lines, if any
+type Ledger struct {
+	mu      sync.Mutex
+	w       io.Writer
+	entries []Entry
+}
+
+// NewLedger creates a Ledger appending to w, kept in memory only if w is nil
+func NewLedger(w io.Writer) *Ledger {
+	return &Ledger{w: w}
+}
+
+// OpenLedger opens the Ledger file at path, creating it if missing, reading back its entries
+// for Parking Lots to carry on from them. Entries are appended to it
+func OpenLedger(path string) (*Ledger, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ReadLedger(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Ledger{w: f, entries: entries}, nil
+}
+
+// Close closes the writer of the Ledger, if it can be closed
+func (l *Ledger) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Record appends the entry to the Ledger
+func (l *Ledger) Record(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	if l.w == nil {
+		return nil
+	}
+	return json.NewEncoder(l.w).Encode(entry)
+}
+
+// Entries returns the entries recorded so far, in order
+func (l *Ledger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// ReadLedger reads the entries a Ledger appended as JSON lines
+func ReadLedger(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	dec := json.NewDecoder(r)
+	for {
+		var entry Entry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
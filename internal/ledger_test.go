@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"bytes"
+	"path/filepath"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	now := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-001", VehicleType: parking.VehicleType_CarSuv, EntryDateTime: now, ExitDateTime: now, Fees: 40, PaymentStatus: parking.PaymentStatus_Settled}},
+		{LotID: "mall-1", Adjustment: &parking.Adjustment{AdjustmentNumber: "A-001", ReceiptNumber: "R-001", Amount: 10, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1", DateTime: now}},
+	}
+	var buf bytes.Buffer
+	l := NewLedger(&buf)
+	for _, entry := range entries {
+		assert.Nil(t, l.Record(entry), "Err must be nil")
+	}
+	assert.Equal(t, entries, l.Entries(), "Entries must be kept in order")
+
+	got, err := ReadLedger(&buf)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, entries, got, "Entries must be read back as recorded")
+}
+
+func TestOpenLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	entry := Entry{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-001", Fees: 40, PaymentStatus: parking.PaymentStatus_Settled}}
+	l, err := OpenLedger(path)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Nil(t, l.Record(entry), "Err must be nil")
+	assert.Nil(t, l.Close(), "Err must be nil")
+
+	l, err = OpenLedger(path)
+	assert.Nil(t, err, "Err must be nil")
+	defer l.Close()
+	assert.Equal(t, []Entry{entry}, l.Entries(), "entries of earlier runs must be read back")
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sahaj/pkg/parking"
+)
+
+// Keys of the fields logged by Parking Lots
+const (
+	LogLot         = "lot"
+	LogTicket      = "ticket"
+	LogVehicleType = "vehicle_type"
+)
+
+// NewLogger creates a structured logger writing to w from the level on, as JSON or text
+func NewLogger(w io.Writer, level slog.Leveler, json bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if json {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// discard is the logger of Parking Lots not given one, logging nothing
+var discard = NewLogger(io.Discard, slog.LevelError+1, false)
+
+// Logger returns the logger the options set, the one logging nothing if none
+func Logger(opts ...Option) *slog.Logger {
+	var p Parking
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.Logger == nil {
+		return discard
+	}
+	return p.Logger
+}
+
+// LogResult logs the outcome of an Action: parks, exits & refunds at info,
+// rejected parks & blocked exits at warn, other failures at debug
+func (p Parking) LogResult(action parking.Action, res parking.Result) {
+	attrs := []slog.Attr{slog.String("action", action.ActionType.String()), slog.String(LogVehicleType, action.VehicleType.String())}
+	if action.TicketNumer != nil {
+		attrs = append(attrs, slog.String(LogTicket, *action.TicketNumer))
+	}
+	level, msg := slog.LevelDebug, "action failed"
+	switch {
+	case res.Err != nil:
+		attrs = append(attrs, slog.String("code", parking.CodeOf(res.Err).String()), slog.String("err", res.Err.Error()))
+		if action.ActionType == parking.ActionType_Park {
+			level, msg = slog.LevelWarn, "park rejected"
+		} else if action.ActionType == parking.ActionType_UnPark {
+			level, msg = slog.LevelWarn, "exit blocked"
+		}
+	case res.ParkingTicket != nil:
+		ticket := res.ParkingTicket
+		level, msg = slog.LevelInfo, "vehicle parked"
+		attrs = append(attrs[:1], slog.String(LogVehicleType, ticket.VehicleType.String()), slog.String(LogTicket, ticket.TicketNumber), slog.Uint64("spot", uint64(ticket.SpotNumber)), slog.Uint64("paid", uint64(ticket.Fees)))
+	case res.ParkingReceipt != nil && action.ActionType == parking.ActionType_UnPark:
+		receipt := res.ParkingReceipt
+		level, msg = slog.LevelInfo, "vehicle exited"
+		attrs = append(attrs, slog.String("receipt", receipt.ReceiptNumber), slog.Uint64("fees", uint64(receipt.Fees)))
+	case res.Adjustment != nil:
+		level, msg = slog.LevelInfo, "refund issued"
+		attrs = append(attrs[:1], slog.String("receipt", res.Adjustment.ReceiptNumber), slog.String("adjustment", res.Adjustment.AdjustmentNumber), slog.Uint64("amount", uint64(res.Adjustment.Amount)), slog.String("operator", res.Adjustment.OperatorID))
+	default:
+		level, msg = slog.LevelDebug, "action done"
+	}
+	p.logger().LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// LogFee logs the fees calculated for the stay of the vehicle against the ticket, at debug
+func (p Parking) LogFee(ticketNo string, receipt parking.Receipt) {
+	p.logger().LogAttrs(context.Background(), slog.LevelDebug, "fee calculated",
+		slog.String(LogTicket, ticketNo),
+		slog.String(LogVehicleType, receipt.VehicleType.String()),
+		slog.Time("entry", receipt.EntryDateTime),
+		slog.Time("exit", receipt.ExitDateTime),
+		slog.String("band", receipt.Band),
+		slog.Uint64("fees", uint64(receipt.Fees)),
+		slog.Uint64("prepaid", uint64(receipt.Prepaid)),
+		slog.Any("line_items", receipt.LineItems),
+	)
+}
+
+func (p Parking) logger() *slog.Logger {
+	if p.Logger == nil {
+		return discard
+	}
+	return p.Logger
+}
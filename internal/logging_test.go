@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"log/slog"
+	"sahaj/pkg/parking"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParking_LogResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  slog.Level
+		action parking.Action
+		result parking.Result
+		want   string
+	}{
+		{
+			name:   "parked vehicle should be logged at info",
+			level:  slog.LevelInfo,
+			action: parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{ParkingTicket: &parking.Ticket{TicketNumber: "001", VehicleType: parking.VehicleType_CarSuv, SpotNumber: 2}},
+			want:   `level=INFO msg="vehicle parked" lot=mall-1 action=Park vehicle_type=Car/Suv ticket=001 spot=2 paid=0`,
+		},
+		{
+			name:   "rejected park should be logged at warn with its code",
+			level:  slog.LevelInfo,
+			action: parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{Err: parking.NewError(parking.ErrNoSpace, "mall-1", "", "")},
+			want:   `level=WARN msg="park rejected" lot=mall-1 action=Park vehicle_type=Car/Suv code=NoSpace err="[NoSpace] lot mall-1: no space available"`,
+		},
+		{
+			name:   "exited vehicle should be logged at info with its ticket",
+			level:  slog.LevelInfo,
+			action: parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: ToStringPtr("001")},
+			result: parking.Result{ParkingReceipt: &parking.Receipt{ReceiptNumber: "R-001", Fees: 40}},
+			want:   `level=INFO msg="vehicle exited" lot=mall-1 action=UnPark vehicle_type=Car/Suv ticket=001 receipt=R-001 fees=40`,
+		},
+		{
+			name:   "other actions should only be logged at debug",
+			level:  slog.LevelInfo,
+			action: parking.Action{ActionType: parking.ActionType_Reserve, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{Reservation: &parking.Reservation{}},
+		},
+		{
+			name:   "other actions should be logged at debug",
+			level:  slog.LevelDebug,
+			action: parking.Action{ActionType: parking.ActionType_Reserve, VehicleType: parking.VehicleType_CarSuv},
+			result: parking.Result{Reservation: &parking.Reservation{}},
+			want:   `level=DEBUG msg="action done" lot=mall-1 action=Reserve vehicle_type=Car/Suv`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p, err := NewParking("mall-1", parking.ModelType_Mall, parking.Fee{}, nil, WithLogger(NewLogger(&buf, tt.level, false)))
+			assert.NoError(t, err, "NewParking must not fail")
+			p.LogResult(tt.action, tt.result)
+			if tt.want == "" {
+				assert.Empty(t, buf.String(), "nothing must be logged")
+				return
+			}
+			// drop the time
+			got := strings.TrimSpace(buf.String())
+			assert.Equal(t, tt.want, got[strings.Index(got, "level="):], "log must match")
+		})
+	}
+}
+
+func TestParking_LogFee(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewParking("mall-1", parking.ModelType_Mall, parking.Fee{}, nil, WithLogger(NewLogger(&buf, slog.LevelDebug, true)))
+	assert.NoError(t, err, "NewParking must not fail")
+	p.LogFee("001", parking.Receipt{VehicleType: parking.VehicleType_CarSuv, Band: "0-4h", Fees: 60})
+
+	got := buf.String()
+	assert.Contains(t, got, `"level":"DEBUG","msg":"fee calculated","lot":"mall-1","ticket":"001","vehicle_type":"Car/Suv"`, "fields must match")
+	assert.Contains(t, got, `"band":"0-4h","fees":60`, "fees must match")
+}
+
+func TestParking_Logger(t *testing.T) {
+	p := Parking{ID: "mall-1"}
+	assert.NotPanics(t, func() { p.LogResult(parking.Action{}, parking.Result{}) }, "Parking without logger must log nothing")
+}
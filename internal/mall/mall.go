@@ -5,85 +5,183 @@ import (
 	"sahaj/internal"
 	"sahaj/pkg/parking"
 	"sort"
-	"strings"
 	"time"
 )
 
 type ParkingLot struct {
-	parking   internal.Parking     // base parking model
-	record    map[string]time.Time // parking record
-	receiptNo uint                 // tracks upcoming receipt
-	padWidth  uint                 // for printing receipt & ticket number
+	parking  internal.Parking  // base parking model
+	record   internal.Records  // parking record
+	booking  internal.Booking  // reservations
+	receipts internal.Receipts // settled receipts & their adjustments
+	ticketNo uint              // tracks upcoming ticket
+	padWidth uint              // for printing receipt & ticket number
 }
 
-func New(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) *ParkingLot {
+func New(id string, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory, opts ...internal.Option) (*ParkingLot, error) {
+	base, err := internal.NewParking(id, parking.ModelType_Mall, fee, inventory, opts...)
+	if err != nil {
+		return nil, err
+	}
 	return &ParkingLot{
-		parking: internal.Parking{
-			Inventory: inventory,
-			Fee:       fee,
+		parking: base,
+		record:  internal.NewRecords(nil),
+		booking: internal.Booking{
+			ReleaseAfter: base.ReservationRelease,
+			EarlyArrival: base.EarlyArrival,
+			HoldAhead:    base.ReservationHold,
+			PadWidth:     3,
 		},
-		record:    map[string]time.Time{},
-		receiptNo: 0,
-		padWidth:  3,
-	}
+		receipts: internal.NewReceipts(id, 3, base.Ledger),
+		ticketNo: 0,
+		padWidth: 3,
+	}, nil
+}
+
+func (p *ParkingLot) GetID() string {
+	return p.parking.ID
 }
 
 func (p *ParkingLot) GetType() parking.ModelType {
 	return parking.ModelType_Mall
 }
 
+// Occupancy takes a snapshot of the spots of the Parking Lot
+func (p *ParkingLot) Occupancy() parking.Occupancy {
+	return p.parking.Occupancy(p.record, &p.booking, p.parking.Now())
+}
+
+// OccupancyChanges returns the occupancy of the Parking Lot every time a vehicle parked or exited
+func (p *ParkingLot) OccupancyChanges() []parking.OccupancyChange {
+	return p.parking.OccupancyChanges()
+}
+
+// Ticket returns the ticket of a vehicle still parked
+func (p *ParkingLot) Ticket(ticketNo string) (parking.Ticket, error) {
+	return p.parking.Ticket(p.record, ticketNo)
+}
+
+// Tickets lists the tickets of vehicles still parked matching the query
+func (p *ParkingLot) Tickets(query parking.TicketQuery) parking.TicketPage {
+	return p.parking.Tickets(p.record, query)
+}
+
 func (p *ParkingLot) Do(action parking.Action) parking.Result {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
 	res := parking.Result{}
 	switch action.ActionType {
 	case parking.ActionType_Park:
 		res.ParkingTicket, res.Err = p.generateParkingTicket(action)
+		if res.Err == nil && p.parking.PaymentTiming == parking.PaymentTiming_OnEntry {
+			res.ParkingReceipt, res.Err = p.generateEntryReceipt(res.ParkingTicket)
+		}
 	case parking.ActionType_UnPark:
 		res.ParkingReceipt, res.Err = p.generateParkingReceipt(action)
+	case parking.ActionType_Reserve:
+		if action.Prepay {
+			res.Err = parking.NewError(parking.ErrChargeNotSupported, p.parking.ID, "", "prepaid reservations are only supported @ "+parking.ModelType_Airport.String())
+			break
+		}
+		res.Reservation, res.Err = p.booking.Reserve(action, p.parking.Inventory, p.parking.Now(), 0)
+	case parking.ActionType_CancelReservation:
+		res.Reservation, res.Err = p.booking.Cancel(action, p.parking.Now())
+	case parking.ActionType_ModifyReservation:
+		res.Reservation, res.Err = p.booking.Modify(action, p.parking.Inventory, p.parking.Now())
+	case parking.ActionType_Refund:
+		res.Adjustment, res.Err = p.receipts.Refund(action, p.parking.Gateway, p.parking.Now())
 	default:
 		res.Err = parking.ErrInvalidAction
 	}
+	res.Err = p.parking.WrapError(action, res.Err)
+	p.parking.LogResult(action, res)
+	p.parking.EmitResult(action, res, p.record, p.parking.Now())
 	return res
 }
 
+// CheckOverstays emits the vehicles parked longer than the maximum stay by now, for a sweep
+// to report them while no action is done at the Parking Lot
+func (p *ParkingLot) CheckOverstays() {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
+}
+
+// SetFee changes the tariff of the Parking Lot, stays in progress are charged as per it on exit
+func (p *ParkingLot) SetFee(fee parking.Fee) {
+	p.parking.SetFee(fee, p.parking.Now())
+}
+
 func (p *ParkingLot) generateParkingTicket(action parking.Action) (*parking.Ticket, error) {
 	inv, ok := p.parking.Inventory[action.VehicleType]
 	if !ok {
-		return nil, parking.ErrVehicleNotAllowed
+		return nil, parking.NewError(parking.ErrVehicleNotAllowed, p.parking.ID, "", action.VehicleType.String()+" has no spots")
+	}
+	now := p.parking.Now()
+	var reservationNo string
+	if action.ReservationNumber != nil {
+		reservationNo = *action.ReservationNumber
+		if err := p.booking.Open(reservationNo, action.VehicleType, now); err != nil {
+			return nil, err
+		}
 	}
-	if len(p.record) < int(inv.Total) {
-		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), len(p.record)+1)
-		key := getRecordKey(tktNo, action.VehicleType)
-		p.record[key] = internal.Now()
-		return &parking.Ticket{
-			TicketNumber:  tktNo,
-			SpotNumber:    uint(len(p.record)),
-			EntryDateTime: internal.Now(),
-		}, nil
+	// spots held for reservations can only be taken by their own vehicle
+	occupied := p.record.Occupied(action.VehicleType) + p.booking.Held(action.VehicleType, now, reservationNo)
+	if occupied < inv.Total {
+		passID, err := p.parking.UsePass(action, now)
+		if err != nil {
+			return nil, err
+		}
+		// the first rate interval is paid on entry, unless covered by a pass
+		var paid uint
+		if p.parking.PaymentTiming == parking.PaymentTiming_OnEntry && passID == "" {
+			paid, err = calculateFee(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: action.VehicleType}, p.parking.Fee, now, now.Add(internal.EntryStay(p.parking.Fee, action.VehicleType)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		paymentID, err := p.parking.Settle(action, paid)
+		if err != nil {
+			return nil, err
+		}
+		p.ticketNo++
+		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), p.ticketNo)
+		rec := &internal.Record{
+			VehicleType:       action.VehicleType,
+			Plate:             action.Plate,
+			SpotNumber:        p.record.FreeSpot(action.VehicleType, inv.Total),
+			EntryDateTime:     now,
+			ReservationNumber: reservationNo,
+			PassID:            passID,
+			Paid:              paid,
+			PaymentID:         paymentID,
+		}
+		p.record.Add(tktNo, rec)
+		p.parking.RecordOccupancy(p.record, action.VehicleType, now)
+		p.booking.Arrive(reservationNo)
+		ticket := rec.Ticket(tktNo)
+		return &ticket, nil
 	}
-	return nil, parking.ErrNoSpace
+	return nil, parking.NewError(parking.ErrNoSpace, p.parking.ID, "", fmt.Sprintf("%d of %d %s spots occupied or reserved", occupied, inv.Total, action.VehicleType))
+}
+
+// generateEntryReceipt issues the receipt of the amount paid on entry
+func (p *ParkingLot) generateEntryReceipt(ticket *parking.Ticket) (*parking.Receipt, error) {
+	rec, _ := p.record.Get(ticket.TicketNumber)
+	return p.receipts.IssueEntry(*ticket, rec.PaymentID)
 }
 
 func (p *ParkingLot) generateParkingReceipt(action parking.Action) (*parking.Receipt, error) {
 	if action.TicketNumer == nil {
 		return nil, parking.ErrInvalidTicket
 	}
-	key := getRecordKey(*action.TicketNumer, action.VehicleType)
-	if parkingTime, ok := p.record[key]; ok {
-		fee, err := calculateFee(action, p.parking.Fee, parkingTime, internal.Now())
-		if err != nil {
-			return nil, err
-		}
-		p.receiptNo++
-		receipt := &parking.Receipt{
-			ReceiptNumber: fmt.Sprintf(fmt.Sprintf("R-%%0%dd", p.padWidth), p.receiptNo),
-			EntryDateTime: parkingTime,
-			ExitDateTime:  internal.Now(),
-			Fees:          fee,
-		}
-		delete(p.record, key)
-		return receipt, nil
+	rec, err := p.record.Lookup(*action.TicketNumer, action.VehicleType)
+	if err != nil {
+		return nil, err
+	}
+	// stay is covered by the pass till it expires, only the stay past it is charged
+	entryTime, _ := p.parking.ChargedFrom(rec)
+	charge, err := p.parking.Charge(calculateFee, action, entryTime, p.parking.Now())
+	if err != nil {
+		return nil, err
 	}
-	return nil, parking.ErrVehicleMismatch
+	return p.parking.Checkout(action, p.record, &p.receipts, *action.TicketNumer, charge)
 }
 
 func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime time.Time) (uint, error) {
@@ -104,32 +202,39 @@ func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime ti
 				break
 			}
 		}
-		if len(rates) == 0 {
+		base, calendar := internal.SplitRates(rates)
+		if len(base) == 0 {
 			return fees, parking.ErrInvalidTicket
 		}
 
 		// make sure the rates are in increasing order of from time
-		ratesSortedByTime := parking.SortRatesByStartTime(rates)
+		ratesSortedByTime := parking.SortRatesByStartTime(base)
 		sort.Sort(ratesSortedByTime)
 
-		hr := uint(parkingDuration.Hours())
-		min := uint(parkingDuration.Minutes())
-		min = min - hr*60
-		fees = hr * ratesSortedByTime[0].Rate
-		if min > 0 {
-			fees += ratesSortedByTime[0].Rate
+		// every started hour is charged, unless a finer rounding unit is set,
+		// counting whole minutes of the stay only as the hour is started by a minute
+		unit := fee.RoundingMinutes
+		billed := parkingDuration
+		if unit == 0 {
+			unit = 60
+			billed = parkingDuration.Truncate(time.Minute)
 		}
+		fees = internal.CapDaily(fee.DailyCap, billed, func(stay time.Duration) uint {
+			// each segment of the stay is billed at the rate of its calendar
+			var rateSeconds uint
+			last := ratesSortedByTime[0]
+			for _, segment := range internal.Split(calendar, ratesSortedByTime[0], fee.Holidays, entryTime, entryTime.Add(stay)) {
+				rateSeconds += uint(segment.Till.Sub(segment.From).Seconds()) * segment.Rate.Rate
+				last = segment.Rate
+			}
+			// rounded up part of the stay is billed at the rate the stay ends at
+			rateSeconds += uint((internal.RoundUp(stay, unit) - stay).Seconds()) * last.Rate
+			return (rateSeconds + 3599) / 3600
+		})
 	case parking.ChargeType_PerDay:
 		fallthrough
 	default:
 		return fees, parking.ErrChargeNotSupported
 	}
-	return fees, nil
-}
-
-func getRecordKey(ticketNo string, vehicleType parking.VehicleType) string {
-	var key strings.Builder
-	key.WriteString(ticketNo)
-	key.WriteString(vehicleType.String())
-	return key.String()
+	return internal.ApplyLimits(fee, parkingDuration, fees), nil
 }
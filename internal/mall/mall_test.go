@@ -3,6 +3,7 @@ package mall
 import (
 	"sahaj/internal"
 	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 
 func TestNew(t *testing.T) {
 	type args struct {
+		id        string
 		fee       parking.Fee
 		inventory map[parking.VehicleType]internal.Inventory
 	}
@@ -41,14 +43,29 @@ func TestNew(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := New(tt.args.fee, tt.args.inventory)
-			assert.Equal(t, len(tt.want.parking.Inventory), len(tt.want.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(tt.want.parking.Inventory))
+			got, err := New(tt.args.id, tt.args.fee, tt.args.inventory)
+			if tt.want == nil {
+				assert.Nil(t, got, "New() must not create Parking Lot")
+				assert.ErrorIs(t, err, parking.ErrVehicleNotAllowed, "New() must fail with %v, got %v", parking.ErrVehicleNotAllowed, err)
+				return
+			}
+			assert.Nil(t, err, "Err must be nil")
+			assert.Equal(t, len(tt.want.parking.Inventory), len(got.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(got.parking.Inventory))
 			assert.Equal(t, tt.want.parking.Fee.Charge, got.parking.Fee.Charge, "ChargeType must match, expected %v got %v", tt.want.parking.Fee.Charge, got.parking.Fee.Charge)
 			assert.Equal(t, len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles), "supported vehicle quantity must match, expected %v got %v", len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles))
 		})
 	}
 }
 
+// newParkingLot creates a Parking Lot for test cases, panics on invalid inventory
+func newParkingLot(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) ParkingLot {
+	p, err := New("", fee, inventory, internal.WithClock(internal.Now))
+	if err != nil {
+		panic(err)
+	}
+	return *p
+}
+
 func TestParkingLot_GetType(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -57,7 +74,7 @@ func TestParkingLot_GetType(t *testing.T) {
 	}{
 		{
 			name:   "Type of Parking Lot should always be Mall",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
 			want:   parking.ModelType_Mall,
 		},
 	}
@@ -81,7 +98,7 @@ func TestParkingLot_Do(t *testing.T) {
 	}{
 		{
 			name: "Motercycle should get parked",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -104,7 +121,7 @@ func TestParkingLot_Do(t *testing.T) {
 		},
 		{
 			name: "Motercycle should not get parked if no space in Parking lot",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 0,
 				},
@@ -125,6 +142,8 @@ func TestParkingLot_Do(t *testing.T) {
 			name: "Motercycle should get un-parked",
 			fields: ParkingLot{
 				parking: internal.Parking{
+					Clock:     internal.Now,
+					Gateway:   payment.NewCash(),
 					Inventory: map[parking.VehicleType]internal.Inventory{},
 					Fee: parking.Fee{
 						Charge: parking.ChargeType_PerHour,
@@ -140,11 +159,15 @@ func TestParkingLot_Do(t *testing.T) {
 						},
 					},
 				},
-				record: map[string]time.Time{
-					"001Motorcycle": internal.Now().Add(-1 * time.Hour),
-				},
-				receiptNo: 0,
-				padWidth:  3,
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-1 * time.Hour),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
 			},
 			args: args{
 				action: parking.Action{
@@ -164,9 +187,62 @@ func TestParkingLot_Do(t *testing.T) {
 				Err: nil,
 			},
 		},
+		{
+			name: "Car/Suv can not be un-parked with Motorcycle ticket",
+			fields: ParkingLot{
+				parking: internal.Parking{
+					Clock:     internal.Now,
+					Inventory: map[parking.VehicleType]internal.Inventory{},
+					Fee: parking.Fee{
+						Charge: parking.ChargeType_PerHour,
+					},
+				},
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-1 * time.Hour),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
+			},
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+					TicketNumer: internal.ToStringPtr("001"),
+				},
+			},
+			want: parking.Result{
+				ParkingTicket:  nil,
+				ParkingReceipt: nil,
+				Err:            parking.ErrVehicleMismatch,
+			},
+		},
+		{
+			name: "Unknown ticket can not be un-parked",
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+				parking.VehicleType_Motorcycle: {
+					Total: 1,
+				},
+			}),
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_Motorcycle,
+					TicketNumer: internal.ToStringPtr("001"),
+				},
+			},
+			want: parking.Result{
+				ParkingTicket:  nil,
+				ParkingReceipt: nil,
+				Err:            parking.ErrInvalidTicket,
+			},
+		},
 		{
 			name: "Invalid parking Action",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -199,11 +275,170 @@ func TestParkingLot_Do(t *testing.T) {
 				assert.Equal(t, tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber, "ReceiptNumber must match, want %v, got %v", tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber)
 				assert.Equal(t, tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees, "Fees must match, want %v, got %v", tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees)
 			}
-			assert.Equal(t, tt.want.Err, got.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
+			assert.ErrorIs(t, got.Err, tt.want.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
 		})
 	}
 }
 
+func TestParkingLot_Do_Pass(t *testing.T) {
+	passes := internal.NewPasses(parking.Pass{
+		PassID:      "P-1",
+		VehicleType: parking.VehicleType_CarSuv,
+		ValidFrom:   internal.Now().Add(-24 * time.Hour),
+		ValidTill:   internal.Now().Add(30 * 24 * time.Hour),
+	})
+	lot, err := New("mall-1", parking.Fee{
+		Charge: parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{
+					{
+						Rate: 20,
+					},
+				},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 1,
+		},
+	}, internal.WithPasses(passes), internal.WithClock(internal.Now))
+	assert.Nil(t, err, "Err must be nil")
+
+	got := lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Park,
+		VehicleType: parking.VehicleType_CarSuv,
+		PassID:      internal.ToStringPtr("P-1"),
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, "P-1", got.ParkingTicket.PassID, "PassID must match")
+
+	got = lot.Do(parking.Action{
+		ActionType:  parking.ActionType_UnPark,
+		VehicleType: parking.VehicleType_CarSuv,
+		TicketNumer: &got.ParkingTicket.TicketNumber,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, "P-1", got.ParkingReceipt.PassID, "PassID must match")
+	assert.Equal(t, uint(0), got.ParkingReceipt.Fees, "stay covered by pass must not be charged")
+
+	got = lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Park,
+		VehicleType: parking.VehicleType_CarSuv,
+		PassID:      internal.ToStringPtr("P-2"),
+	})
+	assert.ErrorIs(t, got.Err, parking.ErrPassNotFound, "unknown pass must be rejected")
+}
+
+func TestParkingLot_Do_Clock(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 30, 0, 0, time.UTC)
+	passes := internal.NewPasses(parking.Pass{
+		PassID:      "P-1",
+		VehicleType: parking.VehicleType_CarSuv,
+		ValidFrom:   now.Add(-time.Minute),
+		ValidTill:   now.Add(time.Hour),
+	})
+	lot, err := New("mall-1", parking.Fee{
+		Charge: parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{
+					{
+						Rate: 20,
+					},
+				},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 1,
+		},
+	}, internal.WithPasses(passes), internal.WithClock(func() time.Time { return now }))
+	assert.Nil(t, err, "Err must be nil")
+
+	got := lot.Do(parking.Action{
+		ActionType:  parking.ActionType_Park,
+		VehicleType: parking.VehicleType_CarSuv,
+		PassID:      internal.ToStringPtr("P-1"),
+	})
+	assert.Nil(t, got.Err, "pass valid since a minute must be accepted")
+	assert.Equal(t, now, got.ParkingTicket.EntryDateTime, "EntryDateTime must be told by the clock")
+
+	now = now.Add(90 * time.Minute)
+	got = lot.Do(parking.Action{
+		ActionType:  parking.ActionType_UnPark,
+		VehicleType: parking.VehicleType_CarSuv,
+		TicketNumer: &got.ParkingTicket.TicketNumber,
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(20), got.ParkingReceipt.Fees, "stay past the pass must be charged")
+}
+
+func TestParkingLot_Do_ReservationHold(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	lot, err := New("mall-1", parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {
+			Total: 1,
+		},
+	}, internal.WithClock(func() time.Time { return now }))
+	assert.Nil(t, err, "Err must be nil")
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Reserve, VehicleType: parking.VehicleType_CarSuv, From: now.Add(time.Minute), Till: now.Add(3 * time.Hour)})
+	assert.Nil(t, got.Err, "Err must be nil")
+	reservationNo := got.Reservation.ReservationNumber
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+	assert.ErrorIs(t, got.Err, parking.ErrNoSpace, "walk-in must not take the spot of a reservation starting soon")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv, ReservationNumber: &reservationNo})
+	assert.Nil(t, got.Err, "vehicle arriving against the reservation must get the spot")
+}
+
+func TestParkingLot_Do_DailyCap(t *testing.T) {
+	lot := ParkingLot{
+		parking: internal.Parking{
+			Clock:     internal.Now,
+			Gateway:   payment.NewCash(),
+			Inventory: map[parking.VehicleType]internal.Inventory{},
+			Fee: parking.Fee{
+				Charge: parking.ChargeType_PerHour,
+				Vehicles: []parking.Vehicle{
+					{
+						Kind: parking.VehicleType_CarSuv,
+						Rates: []parking.Rate{
+							{
+								Rate: 20,
+							},
+						},
+					},
+				},
+				DailyCap: 100,
+			},
+		},
+		record: internal.NewRecords(map[string]*internal.Record{
+			"001": {
+				VehicleType:   parking.VehicleType_CarSuv,
+				SpotNumber:    1,
+				EntryDateTime: internal.Now().Add(-51 * time.Hour),
+			},
+		}),
+		padWidth: 3,
+	}
+	got := lot.Do(parking.Action{
+		ActionType:  parking.ActionType_UnPark,
+		VehicleType: parking.VehicleType_CarSuv,
+		TicketNumer: internal.ToStringPtr("001"),
+	})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(260), got.ParkingReceipt.Fees, "Fees must be capped")
+	assert.Equal(t, []parking.LineItem{
+		{Description: "Parking fee", Amount: 1020},
+		{Description: "Daily cap", Amount: -760},
+	}, got.ParkingReceipt.LineItems, "LineItems must show the cap")
+}
+
 func Test_calculateFee(t *testing.T) {
 	type args struct {
 		action    parking.Action
@@ -347,6 +582,227 @@ func Test_calculateFee(t *testing.T) {
 			want:    20,
 			wantErr: false,
 		},
+		{
+			name: "Car parked for 14 mins within 15 mins grace. Fees: 0",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+					GraceMinutes: 15,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(14 * time.Minute),
+			},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 16 mins past 15 mins grace. Fees: 20",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+					GraceMinutes: 15,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(16 * time.Minute),
+			},
+			want:    20,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 30 secs, without rounding unit. Fees: 0",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(30 * time.Second),
+			},
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 1 hour and 30 secs, without rounding unit. Fees: 20",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(time.Hour + 30*time.Second),
+			},
+			want:    20,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 1 hour and 20 mins, charged per 15 mins. Fees: 30",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+					RoundingMinutes: 15,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(80 * time.Minute),
+			},
+			want:    30,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 10 mins, charged per 15 mins with minimum charge. Fees: 15",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+					RoundingMinutes: 15,
+					MinimumCharge:   15,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(10 * time.Minute),
+			},
+			want:    15,
+			wantErr: false,
+		},
+		{
+			name: "Car parked for 2 days and 3 hours, capped at 100 a day. Fees: 260",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 20,
+								},
+							},
+						},
+					},
+					DailyCap: 100,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(51 * time.Hour),
+			},
+			want:    260,
+			wantErr: false,
+		},
+		{
+			name: "Car parked Friday 8pm till Saturday 2am, night rate after 10pm. Fees: 100",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_CarSuv,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{
+								{
+									Rate: 10,
+								},
+								{
+									Rate: 20,
+									When: &parking.Calendar{
+										StartTime: "22:00",
+										EndTime:   "06:00",
+									},
+								},
+							},
+						},
+					},
+				},
+				entryTime: time.Date(2022, 7, 1, 20, 0, 0, 0, time.UTC),
+				exitTime:  time.Date(2022, 7, 2, 2, 0, 0, 0, time.UTC),
+			},
+			want:    100,
+			wantErr: false,
+		},
 		{
 			name: "Invalid action",
 			args: args{
@@ -466,30 +922,96 @@ func Test_calculateFee(t *testing.T) {
 	}
 }
 
-func Test_getRecordKey(t *testing.T) {
-	type args struct {
-		ticketNo    string
-		vehicleType parking.VehicleType
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{
+func TestParkingLot_Occupancy(t *testing.T) {
+	lot := newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 2,
+		},
+	})
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	assert.Nil(t, got.Err, "Err must be nil")
+
+	occupancy := lot.Occupancy()
+	assert.Equal(t, []parking.VehicleOccupancy{
 		{
-			name: "001Motorcycle",
-			args: args{
-				ticketNo:    "001",
-				vehicleType: parking.VehicleType_Motorcycle,
-			},
-			want: "001Motorcycle",
+			VehicleType: parking.VehicleType_Motorcycle,
+			Spots:       parking.Spots{Total: 2, Occupied: 1, Free: 1},
 		},
+	}, occupancy.Vehicles, "Vehicles must match")
+}
+
+// subscriber records the domain events it is notified of
+type subscriber []parking.DomainEvent
+
+func (s *subscriber) Notify(event parking.DomainEvent) error {
+	*s = append(*s, event)
+	return nil
+}
+
+func TestParkingLot_Do_DomainEvents(t *testing.T) {
+	events := &subscriber{}
+	lot, err := New("mall-1", parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 10}}}},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithDispatcher(internal.NewSync(events)), internal.WithClock(internal.Now))
+	assert.Nil(t, err, "Err must be nil")
+
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.SetFee(parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 20}}}},
+	})
+	exited := lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &parked.ParkingTicket.TicketNumber})
+
+	var types []parking.DomainEventType
+	for _, event := range *events {
+		assert.Equal(t, "mall-1", event.LotID, "LotID must match")
+		types = append(types, event.Type)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getRecordKey(tt.args.ticketNo, tt.args.vehicleType); got != tt.want {
-				t.Errorf("getRecordKey() = %v, want %v", got, tt.want)
-			}
-		})
+	assert.Equal(t, []parking.DomainEventType{
+		parking.DomainEventType_VehicleParked,
+		parking.DomainEventType_LotFull,
+		parking.DomainEventType_ParkRejected,
+		parking.DomainEventType_TariffChanged,
+		parking.DomainEventType_VehicleExited,
+	}, types, "events must match")
+	assert.Equal(t, parking.ErrorCode_NoSpace, (*events)[2].Reason, "Reason must match")
+	assert.Equal(t, parked.ParkingTicket, (*events)[0].Ticket, "Ticket must match")
+	assert.Equal(t, exited.ParkingReceipt, (*events)[4].Receipt, "Receipt must match")
+}
+
+func TestParkingLot_CheckOverstays(t *testing.T) {
+	events := &subscriber{}
+	now := internal.Now()
+	lot, err := New("mall-1", parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 10}}}},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithDispatcher(internal.NewSync(events)), internal.WithMaxStay(4*time.Hour), internal.WithClock(func() time.Time { return now }))
+	assert.Nil(t, err, "Err must be nil")
+
+	parked := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	lot.CheckOverstays()
+	now = now.Add(5 * time.Hour)
+	lot.CheckOverstays()
+	lot.CheckOverstays()
+
+	var types []parking.DomainEventType
+	for _, event := range *events {
+		types = append(types, event.Type)
 	}
+	assert.Equal(t, []parking.DomainEventType{
+		parking.DomainEventType_VehicleParked,
+		parking.DomainEventType_LotFull,
+		parking.DomainEventType_VehicleOverstayed,
+	}, types, "overstay must be emitted once without an action")
+	assert.Equal(t, parked.ParkingTicket.TicketNumber, (*events)[2].Ticket.TicketNumber, "TicketNumber must match")
 }
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"sahaj/internal"
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the fee calculation latency histogram
+var LatencyBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// Metrics collects the activity of Parking Lots for Prometheus, it can be shared between them.
+// It is an http.Handler serving the metrics in the text exposition format
+type Metrics struct {
+	registry
+	parked, exited, rejected, revenue, refunded *family
+	occupied, reserved, spots                   *family
+	latency                                     *family
+}
+
+// New creates the metrics of Parking Lots
+func New() *Metrics {
+	m := &Metrics{}
+	m.parked = m.register("parking_vehicles_parked_total", "Vehicles parked.", counter, nil, "lot", "vehicle_type")
+	m.exited = m.register("parking_vehicles_exited_total", "Vehicles exited.", counter, nil, "lot", "vehicle_type")
+	m.rejected = m.register("parking_actions_rejected_total", "Actions failed, by error code.", counter, nil, "lot", "action", "code")
+	m.revenue = m.register("parking_revenue_total", "Fees settled on receipts.", counter, nil, "lot", "vehicle_type")
+	m.refunded = m.register("parking_refunded_total", "Fees credited back by adjustments.", counter, nil, "lot")
+	m.spots = m.register("parking_spots", "Spots of the vehicle type.", gauge, nil, "lot", "vehicle_type")
+	m.occupied = m.register("parking_spots_occupied", "Spots taken by parked vehicles.", gauge, nil, "lot", "vehicle_type")
+	m.reserved = m.register("parking_spots_reserved", "Spots held for reservations whose vehicle has not arrived yet.", gauge, nil, "lot", "vehicle_type")
+	m.latency = m.register("parking_fee_calculation_seconds", "Time taken to calculate the fees of an exit.", histogram, LatencyBuckets, "lot")
+	return m
+}
+
+// Decorator records the Actions done on Parking Lots in the metrics, for internal.WithDecorators
+func Decorator(m *Metrics) parking.Decorator {
+	return func(lot parking.ParkingLot) parking.ParkingLot {
+		l := &parkingLot{ParkingLot: lot, metrics: m}
+		m.occupancy(lot.Occupancy())
+		return l
+	}
+}
+
+// FeeTimer records how long the fee calculations of exits at Parking Lots take in the metrics,
+// for internal.WithFeeTimer. The calculation is timed inside the Parking Lot, leaving out settling the fees
+func FeeTimer(m *Metrics) internal.FeeTimer {
+	return func(lotID string, took time.Duration) {
+		m.observe(m.latency, took.Seconds(), lotID)
+	}
+}
+
+// parkingLot records the Actions done on the Parking Lot it wraps
+type parkingLot struct {
+	parking.ParkingLot
+	metrics *Metrics
+}
+
+// Unwrap returns the Parking Lot wrapped
+func (p *parkingLot) Unwrap() parking.ParkingLot {
+	return p.ParkingLot
+}
+
+func (p *parkingLot) Do(action parking.Action) parking.Result {
+	res := p.ParkingLot.Do(action)
+	m, lotID := p.metrics, p.GetID()
+	if res.Err != nil {
+		m.add(m.rejected, 1, lotID, action.ActionType.String(), parking.CodeOf(res.Err).String())
+	}
+	switch {
+	case action.ActionType == parking.ActionType_Park && res.ParkingTicket != nil:
+		m.add(m.parked, 1, lotID, res.ParkingTicket.VehicleType.String())
+	case action.ActionType == parking.ActionType_UnPark && res.ParkingReceipt != nil && res.ParkingReceipt.PaymentStatus == parking.PaymentStatus_Settled:
+		m.add(m.exited, 1, lotID, res.ParkingReceipt.VehicleType.String())
+	}
+	if receipt := res.ParkingReceipt; receipt != nil && receipt.PaymentStatus == parking.PaymentStatus_Settled {
+		m.add(m.revenue, float64(receipt.Fees), lotID, receipt.VehicleType.String())
+	}
+	if res.Adjustment != nil {
+		m.add(m.refunded, float64(res.Adjustment.Amount), lotID)
+	}
+	m.occupancy(p.Occupancy())
+	return res
+}
+
+// occupancy sets the gauges to the snapshot, taken after every Action rather than on scrape
+// as Parking Lots are not safe for concurrent use
+func (m *Metrics) occupancy(o parking.Occupancy) {
+	for _, v := range o.Vehicles {
+		vehicleType := v.VehicleType.String()
+		m.set(m.spots, float64(v.Total), o.LotID, vehicleType)
+		m.set(m.occupied, float64(v.Occupied), o.LotID, vehicleType)
+		m.set(m.reserved, float64(v.Reserved), o.LotID, vehicleType)
+	}
+}
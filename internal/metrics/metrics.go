@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metric types of the exposition format
+const (
+	counter   = "counter"
+	gauge     = "gauge"
+	histogram = "histogram"
+)
+
+// family is a metric and its series, one per combination of label values
+type family struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64 // upper bounds of a histogram
+	series           map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // of a counter or gauge, sum of a histogram
+	counts      []uint64 // observations per bucket of a histogram, not cumulative
+	count       uint64
+}
+
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	return s
+}
+
+// registry keeps the families in the order they are exposed
+type registry struct {
+	mu       sync.Mutex
+	families []*family
+}
+
+func (r *registry) register(name, help, kind string, buckets []float64, labels ...string) *family {
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.families = append(r.families, f)
+	return f
+}
+
+// add adds v to the counter or gauge
+func (r *registry) add(f *family, v float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f.get(labelValues).value += v
+}
+
+// set sets the gauge to v
+func (r *registry) set(f *family, v float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f.get(labelValues).value = v
+}
+
+// observe adds an observation of v to the histogram
+func (r *registry) observe(f *family, v float64, labelValues ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := f.get(labelValues)
+	s.value += v
+	s.count++
+	for i, upper := range f.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+}
+
+// WriteText writes the metrics in the Prometheus text exposition format
+func (r *registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, f := range r.families {
+		if len(f.series) == 0 {
+			continue
+		}
+		bw.WriteString("# HELP " + f.name + " " + f.help + "\n")
+		bw.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := f.series[key]
+			if f.kind != histogram {
+				writeSample(bw, f.name, f.labels, s.labelValues, "", "", s.value)
+				continue
+			}
+			var cumulative uint64
+			for i, upper := range f.buckets {
+				cumulative += s.counts[i]
+				writeSample(bw, f.name+"_bucket", f.labels, s.labelValues, "le", formatFloat(upper), float64(cumulative))
+			}
+			writeSample(bw, f.name+"_bucket", f.labels, s.labelValues, "le", "+Inf", float64(s.count))
+			writeSample(bw, f.name+"_sum", f.labels, s.labelValues, "", "", s.value)
+			writeSample(bw, f.name+"_count", f.labels, s.labelValues, "", "", float64(s.count))
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP exposes the metrics for Prometheus to scrape
+func (r *registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = r.WriteText(w)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteString("{")
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString(label + `="` + escape(labelValues[i]) + `"`)
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		w.WriteString("}")
+	}
+	w.WriteString(" " + formatFloat(v) + "\n")
+}
+
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sahaj/internal"
+	"sahaj/internal/mall"
+	"sahaj/pkg/parking"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecorator(t *testing.T) {
+	m := New()
+	// fee calculations are timed by the lot, taking 2ms each
+	timer := func(lotID string, took time.Duration) { FeeTimer(m)(lotID, 2*time.Millisecond) }
+	lot, err := mall.New("mall-1", parking.Fee{
+		Charge:   parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{{Kind: parking.VehicleType_Motorcycle, Rates: []parking.Rate{{Rate: 10}}}},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithClock(internal.Now), internal.WithFeeTimer(timer))
+	assert.NoError(t, err, "mall.New must not fail")
+	decorated := Decorator(m)(lot)
+
+	parked := decorated.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	decorated.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	decorated.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &parked.ParkingTicket.TicketNumber})
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.WriteText(&buf), "WriteText must not fail")
+	assert.Equal(t, `# HELP parking_vehicles_parked_total Vehicles parked.
+# TYPE parking_vehicles_parked_total counter
+parking_vehicles_parked_total{lot="mall-1",vehicle_type="Motorcycle"} 1
+# HELP parking_vehicles_exited_total Vehicles exited.
+# TYPE parking_vehicles_exited_total counter
+parking_vehicles_exited_total{lot="mall-1",vehicle_type="Motorcycle"} 1
+# HELP parking_actions_rejected_total Actions failed, by error code.
+# TYPE parking_actions_rejected_total counter
+parking_actions_rejected_total{lot="mall-1",action="Park",code="NoSpace"} 1
+# HELP parking_revenue_total Fees settled on receipts.
+# TYPE parking_revenue_total counter
+parking_revenue_total{lot="mall-1",vehicle_type="Motorcycle"} 0
+# HELP parking_spots Spots of the vehicle type.
+# TYPE parking_spots gauge
+parking_spots{lot="mall-1",vehicle_type="Motorcycle"} 1
+# HELP parking_spots_occupied Spots taken by parked vehicles.
+# TYPE parking_spots_occupied gauge
+parking_spots_occupied{lot="mall-1",vehicle_type="Motorcycle"} 0
+# HELP parking_spots_reserved Spots held for reservations whose vehicle has not arrived yet.
+# TYPE parking_spots_reserved gauge
+parking_spots_reserved{lot="mall-1",vehicle_type="Motorcycle"} 0
+# HELP parking_fee_calculation_seconds Time taken to calculate the fees of an exit.
+# TYPE parking_fee_calculation_seconds histogram
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.0001"} 0
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.0005"} 0
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.001"} 0
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.005"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.01"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.05"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.1"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="0.5"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="1"} 1
+parking_fee_calculation_seconds_bucket{lot="mall-1",le="+Inf"} 1
+parking_fee_calculation_seconds_sum{lot="mall-1"} 0.002
+parking_fee_calculation_seconds_count{lot="mall-1"} 1
+`, buf.String(), "metrics must match")
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := New()
+	m.add(m.refunded, 15, `lot "1"`)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"), "Content-Type must match")
+	assert.True(t, strings.HasSuffix(rec.Body.String(), `parking_refunded_total{lot="lot \"1\""} 15`+"\n"), "label values must be escaped")
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sahaj/pkg/parking"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timeline keeps the occupancy changes of Parking Lots in order, it can be shared between them.
+// Changes are appended to its writer as JSON lines, if any
+type Timeline struct {
+	mu      sync.Mutex
+	w       io.Writer
+	changes []parking.OccupancyChange
+}
+
+// NewTimeline creates a Timeline appending to w, kept in memory only if w is nil
+func NewTimeline(w io.Writer) *Timeline {
+	return &Timeline{w: w}
+}
+
+// OpenTimeline opens the Timeline file at path, creating it if missing, reading back its changes.
+// Changes are appended to it
+func OpenTimeline(path string) (*Timeline, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := ReadTimeline(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Timeline{w: f, changes: changes}, nil
+}
+
+// Close closes the writer of the Timeline, if it can be closed
+func (t *Timeline) Close() error {
+	if c, ok := t.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Record appends an occupancy change
+func (t *Timeline) Record(change parking.OccupancyChange) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes = append(t.changes, change)
+	if t.w == nil {
+		return nil
+	}
+	return json.NewEncoder(t.w).Encode(change)
+}
+
+// ReadTimeline reads the occupancy changes a Timeline appended as JSON lines
+func ReadTimeline(r io.Reader) ([]parking.OccupancyChange, error) {
+	var changes []parking.OccupancyChange
+	dec := json.NewDecoder(r)
+	for {
+		var change parking.OccupancyChange
+		err := dec.Decode(&change)
+		if err == io.EOF {
+			return changes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+}
+
+// Changes returns the occupancy changes of the Parking Lot, of all Parking Lots if lotID is empty
+func (t *Timeline) Changes(lotID string) []parking.OccupancyChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var changes []parking.OccupancyChange
+	for _, change := range t.changes {
+		if lotID == "" || change.LotID == lotID {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// RecordOccupancy records the occupancy of the vehicle type once a vehicle parked or exited
+func (p Parking) RecordOccupancy(records Records, vehicleType parking.VehicleType, at time.Time) {
+	if p.Timeline == nil {
+		return
+	}
+	// the vehicle has parked or exited already, failing to record it does not fail the action
+	if err := p.Timeline.Record(parking.OccupancyChange{
+		LotID:       p.ID,
+		At:          at,
+		VehicleType: vehicleType,
+		Occupied:    records.Occupied(vehicleType),
+		Total:       p.Inventory[vehicleType].Total,
+	}); err != nil {
+		p.logger().Error("occupancy not recorded", LogVehicleType, vehicleType.String(), "err", err.Error())
+	}
+}
+
+// OccupancyChanges returns the occupancy changes recorded for the Parking Lot
+func (p Parking) OccupancyChanges() []parking.OccupancyChange {
+	if p.Timeline == nil {
+		return nil
+	}
+	return p.Timeline.Changes(p.ID)
+}
+
+// Occupancy takes a snapshot of the spots of the Parking Lot, by vehicle type and level
+func (p Parking) Occupancy(records Records, booking *Booking, now time.Time) parking.Occupancy {
+	vehicleTypes := make([]parking.VehicleType, 0, len(p.Inventory))
+	for vehicleType := range p.Inventory {
+		vehicleTypes = append(vehicleTypes, vehicleType)
+	}
+	sort.Slice(vehicleTypes, func(i, j int) bool { return vehicleTypes[i] < vehicleTypes[j] })
+
+	occupancy := parking.Occupancy{LotID: p.ID, At: now}
+	for _, vehicleType := range vehicleTypes {
+		inv := p.Inventory[vehicleType]
+		vehicle := parking.VehicleOccupancy{
+			VehicleType: vehicleType,
+			Spots: parking.Spots{
+				Total:    inv.Total,
+				Occupied: records.Occupied(vehicleType),
+				Reserved: booking.Held(vehicleType, now, ""),
+			},
+		}
+		vehicle.Free = free(vehicle.Spots)
+		if len(inv.Levels) > 0 {
+			vehicle.Levels = make([]parking.Spots, len(inv.Levels))
+			for i, spots := range inv.Levels {
+				vehicle.Levels[i].Total = spots
+			}
+			for _, rec := range records.Parked() {
+				if rec.VehicleType == vehicleType {
+					vehicle.Levels[inv.Level(rec.SpotNumber)].Occupied++
+				}
+			}
+			for i := range vehicle.Levels {
+				vehicle.Levels[i].Free = free(vehicle.Levels[i])
+			}
+		}
+		occupancy.Vehicles = append(occupancy.Vehicles, vehicle)
+	}
+	return occupancy
+}
+
+func free(spots parking.Spots) uint {
+	if taken := spots.Occupied + spots.Reserved; taken < spots.Total {
+		return spots.Total - taken
+	}
+	return 0
+}
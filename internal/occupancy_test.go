@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"path/filepath"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParking_Occupancy(t *testing.T) {
+	now := Now()
+	p := Parking{
+		ID: "mall-1",
+		Inventory: map[parking.VehicleType]Inventory{
+			parking.VehicleType_CarSuv:     {Total: 2},
+			parking.VehicleType_Motorcycle: {Total: 5, Levels: []uint{2, 3}},
+		},
+	}
+	exited := now.Add(-time.Hour)
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 1},
+		"002": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 3},
+		"003": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 4},
+		"004": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 2, ExitDateTime: &exited},
+		"005": {VehicleType: parking.VehicleType_CarSuv, SpotNumber: 1},
+	})
+	// B-001 holds a Car/Suv spot from 2h, the snapshot is taken at 2h
+	booking := newBooking(now)
+
+	got := p.Occupancy(records, booking, now.Add(2*time.Hour))
+	assert.Equal(t, parking.Occupancy{
+		LotID: "mall-1",
+		At:    now.Add(2 * time.Hour),
+		Vehicles: []parking.VehicleOccupancy{
+			{
+				VehicleType: parking.VehicleType_Motorcycle,
+				Spots:       parking.Spots{Total: 5, Occupied: 3, Free: 2},
+				Levels: []parking.Spots{
+					{Total: 2, Occupied: 1, Free: 1},
+					{Total: 3, Occupied: 2, Free: 1},
+				},
+			},
+			{
+				VehicleType: parking.VehicleType_CarSuv,
+				Spots:       parking.Spots{Total: 2, Occupied: 1, Reserved: 1, Free: 0},
+			},
+		},
+	}, got, "Occupancy must match")
+}
+
+func TestTimeline_Changes(t *testing.T) {
+	now := Now()
+	timeline := &Timeline{}
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 1},
+	})
+	mall := Parking{ID: "mall-1", Timeline: timeline, Inventory: map[parking.VehicleType]Inventory{parking.VehicleType_Motorcycle: {Total: 2}}}
+	stadium := Parking{ID: "stadium-1", Timeline: timeline, Inventory: map[parking.VehicleType]Inventory{parking.VehicleType_Motorcycle: {Total: 5}}}
+	mall.RecordOccupancy(records, parking.VehicleType_Motorcycle, now)
+	stadium.RecordOccupancy(NewRecords(nil), parking.VehicleType_Motorcycle, now.Add(time.Minute))
+
+	assert.Equal(t, []parking.OccupancyChange{
+		{LotID: "mall-1", At: now, VehicleType: parking.VehicleType_Motorcycle, Occupied: 1, Total: 2},
+	}, mall.OccupancyChanges(), "OccupancyChanges must match")
+	assert.Len(t, timeline.Changes(""), 2, "Changes of all Parking Lots must match")
+}
+
+func TestOpenTimeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "occupancy.jsonl")
+	change := parking.OccupancyChange{LotID: "mall-1", At: time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC), VehicleType: parking.VehicleType_Motorcycle, Occupied: 1, Total: 2}
+	timeline, err := OpenTimeline(path)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Nil(t, timeline.Record(change), "Err must be nil")
+	assert.Nil(t, timeline.Close(), "Err must be nil")
+
+	timeline, err = OpenTimeline(path)
+	assert.Nil(t, err, "Err must be nil")
+	defer timeline.Close()
+	assert.Equal(t, []parking.OccupancyChange{change}, timeline.Changes(""), "changes of earlier runs must be read back")
+}
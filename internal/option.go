@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"log/slog"
+	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
+	"time"
+)
+
+// Option configures optional settings of a Parking Lot
+type Option func(*Parking)
+
+// WithReservationRelease sets how long a reservation holds its spot once its window starts
+func WithReservationRelease(d time.Duration) Option {
+	return func(p *Parking) {
+		p.ReservationRelease = d
+	}
+}
+
+// WithEarlyArrival sets how long before its reservation window starts a vehicle can arrive against it
+func WithEarlyArrival(d time.Duration) Option {
+	return func(p *Parking) {
+		p.EarlyArrival = d
+	}
+}
+
+// WithReservationHold sets how long before its reservation window starts a spot is held, walk-ins
+// can not take it meanwhile
+func WithReservationHold(d time.Duration) Option {
+	return func(p *Parking) {
+		p.ReservationHold = d
+	}
+}
+
+// WithPasses lets vehicles park at the Parking Lot with the given passes
+func WithPasses(passes *Passes) Option {
+	return func(p *Parking) {
+		p.Passes = passes
+	}
+}
+
+// WithDiscounts lets vehicles present the codes of the given discounts on exit
+func WithDiscounts(discounts *Discounts) Option {
+	return func(p *Parking) {
+		p.Discounts = discounts
+	}
+}
+
+// WithEvents schedules the given events at the Parking Lot, only honoured by Stadium
+func WithEvents(events *Events) Option {
+	return func(p *Parking) {
+		p.Events = events
+	}
+}
+
+// WithPaymentTiming sets when vehicles pay at the Parking Lot, on exit by default
+func WithPaymentTiming(timing parking.PaymentTiming) Option {
+	return func(p *Parking) {
+		p.PaymentTiming = timing
+	}
+}
+
+// WithGateway sets the payment gateway collecting the fees, cash by default
+func WithGateway(gateway payment.Gateway) Option {
+	return func(p *Parking) {
+		p.Gateway = gateway
+	}
+}
+
+// WithLedger records the receipts & adjustments of the Parking Lot in the given ledger,
+// each Parking Lot keeps its own in memory by default
+func WithLedger(ledger *Ledger) Option {
+	return func(p *Parking) {
+		p.Ledger = ledger
+	}
+}
+
+// WithTimeline records the occupancy changes of the Parking Lot in the given timeline,
+// each Parking Lot keeps its own by default
+func WithTimeline(timeline *Timeline) Option {
+	return func(p *Parking) {
+		p.Timeline = timeline
+	}
+}
+
+// WithDispatcher emits the domain events of the Parking Lot to the given dispatcher
+func WithDispatcher(dispatcher Dispatcher) Option {
+	return func(p *Parking) {
+		p.Dispatcher = dispatcher
+	}
+}
+
+// WithMaxStay sets how long vehicles can park before overstaying
+func WithMaxStay(d time.Duration) Option {
+	return func(p *Parking) {
+		p.MaxStay = d
+	}
+}
+
+// WithDecorators wraps the Parking Lot created by the factory in the given decorators, outermost first
+func WithDecorators(decorators ...parking.Decorator) Option {
+	return func(p *Parking) {
+		p.Decorators = append(p.Decorators, decorators...)
+	}
+}
+
+// Decorators returns the decorators the options wrap a Parking Lot in
+func Decorators(opts ...Option) []parking.Decorator {
+	var p Parking
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p.Decorators
+}
+
+// WithLogger logs the decisions of the Parking Lot, and of the factory creating it, to the given logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Parking) {
+		p.Logger = logger
+	}
+}
+
+// WithClock sets the clock telling the time of Actions at the Parking Lot, time.Now by default
+func WithClock(clock func() time.Time) Option {
+	return func(p *Parking) {
+		p.Clock = clock
+	}
+}
+
+// WithFeeTimer tells the given timer how long the fee calculations of exits at the Parking Lot take
+func WithFeeTimer(timer FeeTimer) Option {
+	return func(p *Parking) {
+		p.FeeTimer = timer
+	}
+}
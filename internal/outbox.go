@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sahaj/pkg/parking"
+	"sync"
+)
+
+// outboxLine is a line of the Outbox file, an event to deliver or the ID of one delivered
+type outboxLine struct {
+	Event     *parking.DomainEvent `json:"event,omitempty"`
+	Delivered string               `json:"delivered,omitempty"`
+}
+
+// Outbox delivers the events at least once: every event is written to its file before being
+// handed over to the next Dispatcher, and marked delivered once it succeeded. Events not
+// delivered are retried by Flush, including those left over when the file was opened
+type Outbox struct {
+	mu      sync.Mutex
+	f       *os.File
+	next    Dispatcher
+	pending []parking.DomainEvent
+}
+
+// NewOutbox opens the outbox file at path, creating it if missing, in front of next
+func NewOutbox(path string, next Dispatcher) (*Outbox, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := readOutbox(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Outbox{f: f, next: next, pending: pending}, nil
+}
+
+// Dispatch writes the event to the outbox then delivers it, the event is kept
+// for Flush to retry if delivery failed
+func (o *Outbox) Dispatch(event parking.DomainEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.write(outboxLine{Event: &event}); err != nil {
+		return err
+	}
+	o.pending = append(o.pending, event)
+	return o.flush()
+}
+
+// Flush retries delivering the pending events in order, stopping at the first failure
+func (o *Outbox) Flush() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.flush()
+}
+
+// Pending returns the events not delivered yet, in order
+func (o *Outbox) Pending() []parking.DomainEvent {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]parking.DomainEvent(nil), o.pending...)
+}
+
+// Close closes the outbox file
+func (o *Outbox) Close() error {
+	return o.f.Close()
+}
+
+// flush keeps events in order, a later event is not delivered before an earlier one
+func (o *Outbox) flush() error {
+	for len(o.pending) > 0 {
+		event := o.pending[0]
+		if err := o.next.Dispatch(event); err != nil {
+			return err
+		}
+		if err := o.write(outboxLine{Delivered: event.ID}); err != nil {
+			return err
+		}
+		o.pending = o.pending[1:]
+	}
+	return nil
+}
+
+func (o *Outbox) write(line outboxLine) error {
+	if err := json.NewEncoder(o.f).Encode(line); err != nil {
+		return err
+	}
+	return o.f.Sync()
+}
+
+// readOutbox returns the events of the outbox not marked delivered, in order
+func readOutbox(r io.Reader) ([]parking.DomainEvent, error) {
+	var events []parking.DomainEvent
+	delivered := map[string]bool{}
+	dec := json.NewDecoder(r)
+	for {
+		var line outboxLine
+		err := dec.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line.Event != nil {
+			events = append(events, *line.Event)
+		} else {
+			delivered[line.Delivered] = true
+		}
+	}
+	var pending []parking.DomainEvent
+	for _, event := range events {
+		if !delivered[event.ID] {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"path/filepath"
+	"sahaj/pkg/parking"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	s := &subscriber{fail: 1}
+	outbox, err := NewOutbox(path, NewSync(s))
+	assert.NoError(t, err, "NewOutbox must not fail")
+
+	assert.Error(t, outbox.Dispatch(parking.DomainEvent{ID: "1"}), "failed delivery must be returned")
+	assert.Equal(t, []parking.DomainEvent{{ID: "1"}}, outbox.Pending(), "failed event must be pending")
+	assert.NoError(t, outbox.Dispatch(parking.DomainEvent{ID: "2"}), "Dispatch must not fail")
+	assert.Empty(t, outbox.Pending(), "pending event must be delivered first")
+	assert.Equal(t, []parking.DomainEvent{{ID: "1"}, {ID: "2"}}, s.events, "events must be delivered in order")
+
+	// the event written but not delivered when the process stopped is delivered once reopened
+	s.fail = 1
+	assert.Error(t, outbox.Dispatch(parking.DomainEvent{ID: "3"}), "failed delivery must be returned")
+	assert.NoError(t, outbox.Close(), "Close must not fail")
+
+	reopened, err := NewOutbox(path, NewSync(s))
+	assert.NoError(t, err, "NewOutbox must not fail")
+	defer reopened.Close()
+	assert.Equal(t, []parking.DomainEvent{{ID: "3"}}, reopened.Pending(), "undelivered event must be pending")
+	assert.NoError(t, reopened.Flush(), "Flush must not fail")
+	assert.Empty(t, reopened.Pending(), "pending event must be delivered")
+	assert.Len(t, s.events, 3, "every event must be delivered")
+}
@@ -1,14 +1,195 @@
 package internal
 
-import "sahaj/pkg/parking"
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
+	"time"
+)
 
 // Parking represents a Parking Lot
 type Parking struct {
-	Inventory map[parking.VehicleType]Inventory
-	Fee       parking.Fee
+	ID                 string
+	Inventory          map[parking.VehicleType]Inventory
+	Fee                parking.Fee
+	ReservationRelease time.Duration // no-show timeout of reservations
+	EarlyArrival       time.Duration // how early vehicles can arrive against their reservation
+	ReservationHold    time.Duration // how long before reservation windows start their spots are held
+	Passes             *Passes       // passes vehicles can park with, none if nil
+	Discounts          *Discounts    // discounts vehicles can present on exit, none if nil
+	Events             *Events       // events with a flat fee on entry, none if nil
+	PaymentTiming      parking.PaymentTiming
+	Gateway            payment.Gateway     // collects the fees
+	Ledger             *Ledger             // history of receipts & adjustments
+	Timeline           *Timeline           // history of occupancy
+	Dispatcher         Dispatcher          // domain events are dispatched to, none if nil
+	MaxStay            time.Duration       // vehicles parked longer overstay, no limit if 0
+	Decorators         []parking.Decorator // wrapped around the Parking Lot by the factory, outermost first
+	Logger             *slog.Logger        // logs the decisions of the Parking Lot, with its ID
+	Clock              func() time.Time    // tells the time of Actions, time.Now if nil
+	FeeTimer           FeeTimer            // told how long the fee calculations of exits take, none if nil
 }
 
+// FeeTimer is told how long calculating the fees of an exit at the Parking Lot took
+type FeeTimer func(lotID string, took time.Duration)
+
 // Inventory represents actual parking spot
 type Inventory struct {
-	Total uint
+	Total  uint
+	Levels []uint // spots per level, numbered level by level, all on one level if empty
+}
+
+// Level returns the index of the level the spot is on
+func (i Inventory) Level(spot uint) int {
+	var upto uint
+	for level, spots := range i.Levels {
+		upto += spots
+		if spot <= upto {
+			return level
+		}
+	}
+	return len(i.Levels) - 1
+}
+
+// NewParking validates the inventory against the vehicles allowed for the model of Parking Lot
+func NewParking(id string, modelType parking.ModelType, fee parking.Fee, inventory map[parking.VehicleType]Inventory, opts ...Option) (Parking, error) {
+	for vehicleType := range inventory {
+		if !modelType.Allows(vehicleType) {
+			return Parking{}, parking.NewError(parking.ErrVehicleNotAllowed, id, "", vehicleType.String()+" can not be parked @ "+modelType.String())
+		}
+		if inv := inventory[vehicleType]; len(inv.Levels) > 0 {
+			var spots uint
+			for _, level := range inv.Levels {
+				spots += level
+			}
+			if spots != inv.Total {
+				return Parking{}, parking.NewError(parking.ErrInvalidInventory, id, "", fmt.Sprintf("%d of %d %s spots on levels", spots, inv.Total, vehicleType))
+			}
+		}
+	}
+	if err := CheckCalendars(fee); err != nil {
+		return Parking{}, parking.NewError(err, id, "", "")
+	}
+	p := Parking{
+		ID:                 id,
+		Inventory:          inventory,
+		Fee:                fee,
+		ReservationRelease: DefaultReservationRelease,
+		EarlyArrival:       DefaultEarlyArrival,
+		ReservationHold:    DefaultHoldAhead,
+		PaymentTiming:      parking.PaymentTiming_OnExit,
+		Gateway:            payment.NewCash(),
+		Ledger:             NewLedger(nil),
+		Timeline:           &Timeline{},
+		Clock:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.Logger == nil {
+		p.Logger = discard
+	}
+	p.Logger = p.Logger.With(LogLot, id)
+	return p, nil
+}
+
+// Now returns the time as per the clock of the Parking Lot
+func (p Parking) Now() time.Time {
+	if p.Clock == nil {
+		return time.Now()
+	}
+	return p.Clock()
+}
+
+// UsePass validates the pass of the action for the vehicle parking now, counting its use
+func (p Parking) UsePass(action parking.Action, now time.Time) (string, error) {
+	if action.PassID == nil {
+		return "", nil
+	}
+	if p.Passes == nil {
+		return "", parking.NewError(parking.ErrPassNotFound, p.ID, "", "pass "+*action.PassID)
+	}
+	pass, err := p.Passes.Use(*action.PassID, p.ID, action.VehicleType, now)
+	if err != nil {
+		return "", err
+	}
+	return pass.PassID, nil
+}
+
+// PassValidTill returns till when the stay of a vehicle parked with the pass is covered by it
+func (p Parking) PassValidTill(passID string) (time.Time, bool) {
+	if passID == "" || p.Passes == nil {
+		return time.Time{}, false
+	}
+	pass, ok := p.Passes.Get(passID)
+	if !ok {
+		return time.Time{}, false
+	}
+	return pass.ValidTill, true
+}
+
+// EventAt returns the event on at the time, if any
+func (p Parking) EventAt(t time.Time) (parking.Event, bool) {
+	if p.Events == nil {
+		return parking.Event{}, false
+	}
+	return p.Events.At(t)
+}
+
+// ApplyDiscounts applies the discount codes of the action to the fees of the stay,
+// returning fees left to pay and a line item per discount
+func (p Parking) ApplyDiscounts(action parking.Action, entryTime, exitTime time.Time, fees uint, calculate FeeFunc) (uint, []parking.LineItem, error) {
+	if len(action.DiscountCodes) == 0 {
+		return fees, nil, nil
+	}
+	if p.Discounts == nil {
+		return fees, nil, parking.NewError(parking.ErrDiscountNotFound, p.ID, "", "no discounts @ this Parking Lot")
+	}
+	return p.Discounts.Apply(action.DiscountCodes, entryTime, exitTime, fees, calculate)
+}
+
+// Settle collects the amount from the payment source of the action through the gateway,
+// returning the payment ID, none if there is nothing to pay
+func (p Parking) Settle(action parking.Action, amount uint) (string, error) {
+	if amount == 0 {
+		return "", nil
+	}
+	var ticketNo string
+	if action.TicketNumer != nil {
+		ticketNo = *action.TicketNumer
+	}
+	if p.Gateway == nil {
+		return "", parking.NewError(parking.ErrPaymentFailed, p.ID, ticketNo, "no payment gateway")
+	}
+	authorizationID, err := p.Gateway.Authorize(amount, action.PaymentSource)
+	if err != nil {
+		return "", parking.NewError(parking.ErrPaymentFailed, p.ID, ticketNo, err.Error())
+	}
+	paymentID, err := p.Gateway.Capture(authorizationID)
+	if err != nil {
+		return "", parking.NewError(parking.ErrPaymentFailed, p.ID, ticketNo, err.Error())
+	}
+	return paymentID, nil
+}
+
+// WrapError adds the Parking Lot & ticket context to an error returned by an Action,
+// errors already carrying the context only get the Parking Lot ID if missing
+func (p Parking) WrapError(action parking.Action, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *parking.Error
+	if errors.As(err, &e) {
+		if e.LotID == "" {
+			e.LotID = p.ID
+		}
+		return err
+	}
+	var ticketNo string
+	if action.TicketNumer != nil {
+		ticketNo = *action.TicketNumer
+	}
+	return parking.NewError(err, p.ID, ticketNo, "")
 }
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"errors"
+	"sahaj/pkg/parking"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParking_WrapError(t *testing.T) {
+	lot := Parking{ID: "mall-1"}
+	tests := []struct {
+		name   string
+		action parking.Action
+		err    error
+		want   *parking.Error
+	}{
+		{
+			name:   "sentinel should get Parking Lot & ticket context",
+			action: parking.Action{TicketNumer: ToStringPtr("001")},
+			err:    parking.ErrVehicleMismatch,
+			want: &parking.Error{
+				Code:         parking.ErrorCode_VehicleMismatch,
+				LotID:        "mall-1",
+				TicketNumber: "001",
+				Err:          parking.ErrVehicleMismatch,
+			},
+		},
+		{
+			name:   "typed error should get its own code",
+			action: parking.Action{TicketNumer: ToStringPtr("002")},
+			err:    &parking.TicketExitedError{TicketNumber: "002"},
+			want: &parking.Error{
+				Code:         parking.ErrorCode_TicketExited,
+				LotID:        "mall-1",
+				TicketNumber: "002",
+				Err:          &parking.TicketExitedError{TicketNumber: "002"},
+			},
+		},
+		{
+			name:   "error already carrying context should be left as is",
+			action: parking.Action{},
+			err:    parking.NewError(parking.ErrNoSpace, "mall-1", "", "2 of 2 Motorcycle spots occupied"),
+			want: &parking.Error{
+				Code:    parking.ErrorCode_NoSpace,
+				LotID:   "mall-1",
+				Details: "2 of 2 Motorcycle spots occupied",
+				Err:     parking.ErrNoSpace,
+			},
+		},
+		{
+			name:   "nil error should stay nil",
+			action: parking.Action{},
+			err:    nil,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lot.WrapError(tt.action, tt.err)
+			if tt.want == nil {
+				assert.Nil(t, got, "Err must be nil")
+				return
+			}
+			assert.Equal(t, tt.want, got, "Err must match, want %v, got %v", tt.want, got)
+			assert.True(t, errors.Is(got, tt.err), "Err must wrap %v", tt.err)
+		})
+	}
+}
+
+func TestNewParking(t *testing.T) {
+	type args struct {
+		modelType parking.ModelType
+		fee       parking.Fee
+		inventory map[parking.VehicleType]Inventory
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "Bus/Truck can be parked @ Mall",
+			args: args{
+				modelType: parking.ModelType_Mall,
+				inventory: map[parking.VehicleType]Inventory{
+					parking.VehicleType_BusTruck: {Total: 1},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Bus/Truck can not be parked @ Stadium",
+			args: args{
+				modelType: parking.ModelType_Stadium,
+				inventory: map[parking.VehicleType]Inventory{
+					parking.VehicleType_Motorcycle: {Total: 1},
+					parking.VehicleType_BusTruck:   {Total: 1},
+				},
+			},
+			wantErr: parking.ErrVehicleNotAllowed,
+		},
+		{
+			name: "spots on levels must add up to the total",
+			args: args{
+				modelType: parking.ModelType_Mall,
+				inventory: map[parking.VehicleType]Inventory{
+					parking.VehicleType_CarSuv: {Total: 10, Levels: []uint{4, 4}},
+				},
+			},
+			wantErr: parking.ErrInvalidInventory,
+		},
+		{
+			name: "calendar rate with an invalid time of day can not be loaded",
+			args: args{
+				modelType: parking.ModelType_Mall,
+				fee: parking.Fee{
+					Vehicles: []parking.Vehicle{
+						{
+							Kind:  parking.VehicleType_CarSuv,
+							Rates: []parking.Rate{{Rate: 20, When: &parking.Calendar{StartTime: "10pm", EndTime: "06:00"}}},
+						},
+					},
+				},
+				inventory: map[parking.VehicleType]Inventory{
+					parking.VehicleType_CarSuv: {Total: 1},
+				},
+			},
+			wantErr: parking.ErrInvalidCalendar,
+		},
+		{
+			name: "Invalid model does not allow any vehicle",
+			args: args{
+				inventory: map[parking.VehicleType]Inventory{
+					parking.VehicleType_Motorcycle: {Total: 1},
+				},
+			},
+			wantErr: parking.ErrVehicleNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParking("lot-1", tt.args.modelType, tt.args.fee, tt.args.inventory)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, "lot-1", got.ID, "ID must match")
+				assert.Equal(t, tt.args.inventory, got.Inventory, "Inventory must match")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+	"sahaj/pkg/parking"
+	"sync"
+	"time"
+)
+
+// Passes holds the passes issued to vehicles, it can be shared between Parking Lots
+type Passes struct {
+	mu     sync.Mutex
+	passes map[string]*parking.Pass
+}
+
+// NewPasses creates Passes holding the given passes
+func NewPasses(passes ...parking.Pass) *Passes {
+	p := &Passes{passes: map[string]*parking.Pass{}}
+	for _, pass := range passes {
+		p.Add(pass)
+	}
+	return p
+}
+
+// Add issues a pass, replacing the one with same PassID if any
+func (p *Passes) Add(pass parking.Pass) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.passes[pass.PassID] = &pass
+}
+
+// Get returns the pass whatever its validity
+func (p *Passes) Get(passID string) (parking.Pass, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pass, ok := p.passes[passID]
+	if !ok {
+		return parking.Pass{}, false
+	}
+	return *pass, true
+}
+
+// Use validates the pass for the vehicle parking at the Parking Lot and counts the use
+func (p *Passes) Use(passID, lotID string, vehicleType parking.VehicleType, now time.Time) (parking.Pass, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pass, ok := p.passes[passID]
+	if !ok {
+		return parking.Pass{}, parking.NewError(parking.ErrPassNotFound, "", "", "pass "+passID)
+	}
+	if pass.VehicleType != vehicleType {
+		return parking.Pass{}, parking.NewError(parking.ErrVehicleMismatch, "", "", "pass "+passID+" is for "+pass.VehicleType.String())
+	}
+	if now.Before(pass.ValidFrom) || !now.Before(pass.ValidTill) {
+		return parking.Pass{}, parking.NewError(parking.ErrPassExpired, "", "", fmt.Sprintf("pass %s is valid from %s till %s", passID, pass.ValidFrom.Format(time.RFC3339), pass.ValidTill.Format(time.RFC3339)))
+	}
+	if !pass.ValidAt(lotID) {
+		return parking.Pass{}, parking.NewError(parking.ErrPassNotAllowed, "", "", "pass "+passID)
+	}
+	if pass.MaxUses > 0 && pass.Used >= pass.MaxUses {
+		return parking.Pass{}, parking.NewError(parking.ErrPassOverused, "", "", fmt.Sprintf("pass %s used %d of %d times", passID, pass.Used, pass.MaxUses))
+	}
+	pass.Used++
+	return *pass, nil
+}
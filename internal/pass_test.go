@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasses_Use(t *testing.T) {
+	now := Now()
+	newPasses := func() *Passes {
+		return NewPasses(
+			parking.Pass{
+				PassID:      "P-1",
+				VehicleType: parking.VehicleType_CarSuv,
+				ValidFrom:   now.Add(-24 * time.Hour),
+				ValidTill:   now.Add(24 * time.Hour),
+				Lots:        []string{"mall-1"},
+				MaxUses:     2,
+				Used:        1,
+			},
+			parking.Pass{
+				PassID:      "P-2",
+				VehicleType: parking.VehicleType_Motorcycle,
+				ValidFrom:   now.Add(-48 * time.Hour),
+				ValidTill:   now.Add(-24 * time.Hour),
+			},
+		)
+	}
+	type args struct {
+		passID      string
+		lotID       string
+		vehicleType parking.VehicleType
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantUsed uint
+		wantErr  error
+	}{
+		{
+			name:     "valid pass should be used",
+			args:     args{passID: "P-1", lotID: "mall-1", vehicleType: parking.VehicleType_CarSuv},
+			wantUsed: 2,
+		},
+		{
+			name:    "unknown pass can not be used",
+			args:    args{passID: "P-3", lotID: "mall-1", vehicleType: parking.VehicleType_CarSuv},
+			wantErr: parking.ErrPassNotFound,
+		},
+		{
+			name:    "pass can not be used by another vehicle type",
+			args:    args{passID: "P-1", lotID: "mall-1", vehicleType: parking.VehicleType_Motorcycle},
+			wantErr: parking.ErrVehicleMismatch,
+		},
+		{
+			name:    "pass can not be used at another lot",
+			args:    args{passID: "P-1", lotID: "mall-2", vehicleType: parking.VehicleType_CarSuv},
+			wantErr: parking.ErrPassNotAllowed,
+		},
+		{
+			name:    "expired pass can not be used",
+			args:    args{passID: "P-2", lotID: "mall-1", vehicleType: parking.VehicleType_Motorcycle},
+			wantErr: parking.ErrPassExpired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newPasses().Use(tt.args.passID, tt.args.lotID, tt.args.vehicleType, now)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantUsed, got.Used, "Used must match")
+			}
+		})
+	}
+}
+
+func TestPasses_Use_Overused(t *testing.T) {
+	now := Now()
+	passes := NewPasses(parking.Pass{
+		PassID:      "P-1",
+		VehicleType: parking.VehicleType_CarSuv,
+		ValidFrom:   now,
+		ValidTill:   now.Add(24 * time.Hour),
+		MaxUses:     1,
+	})
+	_, err := passes.Use("P-1", "mall-1", parking.VehicleType_CarSuv, now)
+	assert.Nil(t, err, "Err must be nil")
+	_, err = passes.Use("P-1", "mall-1", parking.VehicleType_CarSuv, now)
+	assert.ErrorIs(t, err, parking.ErrPassOverused, "pass must not be used more than MaxUses")
+}
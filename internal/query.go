@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sort"
+	"strings"
+)
+
+// Ticket returns the ticket of a vehicle still parked
+func (p Parking) Ticket(records Records, ticketNo string) (parking.Ticket, error) {
+	rec, ok := records.Get(ticketNo)
+	if !ok {
+		// tells apart an exited ticket from an unknown one
+		_, err := records.Lookup(ticketNo, 0)
+		return parking.Ticket{}, p.WrapError(parking.Action{TicketNumer: &ticketNo}, err)
+	}
+	return rec.Ticket(ticketNo), nil
+}
+
+// Tickets returns the page of tickets of vehicles still parked matching the query, in order of entry
+func (p Parking) Tickets(records Records, query parking.TicketQuery) parking.TicketPage {
+	var tickets []parking.Ticket
+	for ticketNo, rec := range records.Parked() {
+		if p.matches(rec, query) {
+			tickets = append(tickets, rec.Ticket(ticketNo))
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if !tickets[i].EntryDateTime.Equal(tickets[j].EntryDateTime) {
+			return tickets[i].EntryDateTime.Before(tickets[j].EntryDateTime)
+		}
+		return tickets[i].TicketNumber < tickets[j].TicketNumber
+	})
+	page := parking.TicketPage{Total: len(tickets)}
+	// a negative offset starts from the first ticket, a negative limit is no limit
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(tickets) {
+		return page
+	}
+	tickets = tickets[offset:]
+	if query.Limit > 0 && query.Limit < len(tickets) {
+		tickets = tickets[:query.Limit]
+	}
+	page.Tickets = tickets
+	return page
+}
+
+func (p Parking) matches(rec *Record, query parking.TicketQuery) bool {
+	if query.VehicleType != 0 && rec.VehicleType != query.VehicleType {
+		return false
+	}
+	if !query.EnteredFrom.IsZero() && rec.EntryDateTime.Before(query.EnteredFrom) {
+		return false
+	}
+	if !query.EnteredTill.IsZero() && !rec.EntryDateTime.Before(query.EnteredTill) {
+		return false
+	}
+	if query.Plate != "" && !strings.Contains(strings.ToUpper(rec.Plate), strings.ToUpper(query.Plate)) {
+		return false
+	}
+	if query.SpotNumber != 0 && rec.SpotNumber != query.SpotNumber {
+		return false
+	}
+	if query.Level != 0 {
+		inv := p.Inventory[rec.VehicleType]
+		if len(inv.Levels) == 0 {
+			return query.Level == 1
+		}
+		return inv.Level(rec.SpotNumber) == int(query.Level)-1
+	}
+	return true
+}
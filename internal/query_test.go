@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParking_Tickets(t *testing.T) {
+	now := Now()
+	p := Parking{
+		ID: "mall-1",
+		Inventory: map[parking.VehicleType]Inventory{
+			parking.VehicleType_CarSuv:     {Total: 4, Levels: []uint{2, 2}},
+			parking.VehicleType_Motorcycle: {Total: 2},
+		},
+	}
+	exited := now
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_CarSuv, Plate: "KA01AB1234", SpotNumber: 1, EntryDateTime: now.Add(1 * time.Hour)},
+		"002": {VehicleType: parking.VehicleType_CarSuv, Plate: "MH02CD5678", SpotNumber: 3, EntryDateTime: now.Add(2 * time.Hour)},
+		"003": {VehicleType: parking.VehicleType_Motorcycle, Plate: "KA03EF9012", SpotNumber: 1, EntryDateTime: now.Add(3 * time.Hour)},
+		"004": {VehicleType: parking.VehicleType_CarSuv, Plate: "KA04GH3456", SpotNumber: 2, EntryDateTime: now, ExitDateTime: &exited},
+	})
+	tests := []struct {
+		name      string
+		query     parking.TicketQuery
+		want      []string
+		wantTotal int
+	}{
+		{
+			name:      "all vehicles still parked in order of entry",
+			query:     parking.TicketQuery{},
+			want:      []string{"001", "002", "003"},
+			wantTotal: 3,
+		},
+		{
+			name:      "by vehicle type",
+			query:     parking.TicketQuery{VehicleType: parking.VehicleType_CarSuv},
+			want:      []string{"001", "002"},
+			wantTotal: 2,
+		},
+		{
+			name:      "by part of the plate",
+			query:     parking.TicketQuery{Plate: "ka0"},
+			want:      []string{"001", "003"},
+			wantTotal: 2,
+		},
+		{
+			name:      "by entry time window",
+			query:     parking.TicketQuery{EnteredFrom: now.Add(2 * time.Hour), EnteredTill: now.Add(3 * time.Hour)},
+			want:      []string{"002"},
+			wantTotal: 1,
+		},
+		{
+			name:      "by level",
+			query:     parking.TicketQuery{VehicleType: parking.VehicleType_CarSuv, Level: 2},
+			want:      []string{"002"},
+			wantTotal: 1,
+		},
+		{
+			name:      "by spot",
+			query:     parking.TicketQuery{SpotNumber: 1},
+			want:      []string{"001", "003"},
+			wantTotal: 2,
+		},
+		{
+			name:      "page of the matching tickets",
+			query:     parking.TicketQuery{Offset: 1, Limit: 1},
+			want:      []string{"002"},
+			wantTotal: 3,
+		},
+		{
+			name:      "negative page should start from the first ticket, without limit",
+			query:     parking.TicketQuery{Offset: -1, Limit: -1},
+			want:      []string{"001", "002", "003"},
+			wantTotal: 3,
+		},
+		{
+			name:      "page past the matching tickets",
+			query:     parking.TicketQuery{Offset: 3},
+			want:      nil,
+			wantTotal: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Tickets(records, tt.query)
+			var ticketNos []string
+			for _, ticket := range got.Tickets {
+				ticketNos = append(ticketNos, ticket.TicketNumber)
+			}
+			assert.Equal(t, tt.want, ticketNos, "Tickets must match")
+			assert.Equal(t, tt.wantTotal, got.Total, "Total must match")
+		})
+	}
+}
+
+func TestParking_Ticket(t *testing.T) {
+	now := Now()
+	p := Parking{ID: "mall-1"}
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_CarSuv, Plate: "KA01AB1234", SpotNumber: 1, EntryDateTime: now},
+		"002": {VehicleType: parking.VehicleType_CarSuv, SpotNumber: 2, EntryDateTime: now, ExitDateTime: &now},
+	})
+	tests := []struct {
+		name     string
+		ticketNo string
+		want     parking.Ticket
+		wantErr  error
+	}{
+		{
+			name:     "ticket of a parked vehicle",
+			ticketNo: "001",
+			want:     parking.Ticket{TicketNumber: "001", VehicleType: parking.VehicleType_CarSuv, Plate: "KA01AB1234", SpotNumber: 1, EntryDateTime: now},
+		},
+		{
+			name:     "ticket of an exited vehicle",
+			ticketNo: "002",
+			wantErr:  parking.ErrInvalidTicket,
+		},
+		{
+			name:     "unknown ticket",
+			ticketNo: "003",
+			wantErr:  parking.ErrInvalidTicket,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Ticket(records, tt.ticketNo)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			assert.Equal(t, tt.want, got, "Ticket must match")
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"fmt"
+	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SystemOperator issues the adjustments no operator asked for, like the refund of a cancelled reservation
+const SystemOperator = "system"
+
+// Receipts issues the receipts of a Parking Lot and the adjustments against them,
+// the zero value is ready to use
+type Receipts struct {
+	LotID        string
+	PadWidth     uint    // for printing receipt & adjustment number
+	Ledger       *Ledger // receipts & adjustments are recorded in, if any
+	receipts     map[string]*parking.Receipt
+	adjustments  []parking.Adjustment
+	receiptNo    uint // tracks upcoming receipt
+	adjustmentNo uint // tracks upcoming adjustment
+}
+
+// NewReceipts creates the Receipts of the Parking Lot, carrying on from the receipts & adjustments
+// of it recorded in the Ledger, so their numbers are not issued again
+func NewReceipts(lotID string, padWidth uint, ledger *Ledger) Receipts {
+	r := Receipts{LotID: lotID, PadWidth: padWidth, Ledger: ledger, receipts: map[string]*parking.Receipt{}}
+	if ledger == nil {
+		return r
+	}
+	for _, entry := range ledger.Entries() {
+		if entry.LotID != lotID {
+			continue
+		}
+		if entry.Receipt != nil {
+			receipt := *entry.Receipt
+			r.receipts[receipt.ReceiptNumber] = &receipt
+			r.receiptNo = max(r.receiptNo, sequence(receipt.ReceiptNumber, "R-"))
+		}
+		if adjustment := entry.Adjustment; adjustment != nil {
+			if receipt, ok := r.receipts[adjustment.ReceiptNumber]; ok {
+				receipt.Refunded += adjustment.Amount
+				if receipt.Refunded >= receipt.Fees {
+					receipt.PaymentStatus = parking.PaymentStatus_Refunded
+				}
+			}
+			r.adjustments = append(r.adjustments, *adjustment)
+			r.adjustmentNo = max(r.adjustmentNo, sequence(adjustment.AdjustmentNumber, "A-"))
+		}
+	}
+	return r
+}
+
+// Issue numbers the receipt once its payment is collected, marking it settled, and adds it
+func (r *Receipts) Issue(receipt *parking.Receipt) error {
+	r.receiptNo++
+	receipt.ReceiptNumber = fmt.Sprintf(fmt.Sprintf("R-%%0%dd", r.PadWidth), r.receiptNo)
+	receipt.PaymentStatus = parking.PaymentStatus_Settled
+	return r.Add(*receipt)
+}
+
+// IssueEntry issues the receipt of the amount paid on entry against the ticket
+func (r *Receipts) IssueEntry(ticket parking.Ticket, paymentID string) (*parking.Receipt, error) {
+	receipt := &parking.Receipt{
+		VehicleType:   ticket.VehicleType,
+		EntryDateTime: ticket.EntryDateTime,
+		IssuedAt:      ticket.EntryDateTime,
+		Fees:          ticket.Fees,
+		PassID:        ticket.PassID,
+		Surge:         ticket.Surge,
+		PaymentID:     paymentID,
+		LineItems:     []parking.LineItem{{Description: "Parking fee", Amount: int(ticket.Fees)}},
+	}
+	return receipt, r.Issue(receipt)
+}
+
+// Add keeps a copy of a settled receipt, recording it in the Ledger
+func (r *Receipts) Add(receipt parking.Receipt) error {
+	if r.receipts == nil {
+		r.receipts = map[string]*parking.Receipt{}
+	}
+	settled := receipt // the ledger keeps the receipt as settled, adjustments are recorded apart
+	r.receipts[receipt.ReceiptNumber] = &receipt
+	return r.record(Entry{LotID: r.LotID, Receipt: &settled})
+}
+
+// Get returns the receipt as adjusted so far
+func (r *Receipts) Get(receiptNo string) (parking.Receipt, bool) {
+	receipt, ok := r.receipts[receiptNo]
+	if !ok {
+		return parking.Receipt{}, false
+	}
+	return *receipt, true
+}
+
+// Adjustments returns the adjustments issued against the receipt
+func (r *Receipts) Adjustments(receiptNo string) []parking.Adjustment {
+	var adjustments []parking.Adjustment
+	for _, adjustment := range r.adjustments {
+		if adjustment.ReceiptNumber == receiptNo {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	return adjustments
+}
+
+// Refund credits back part or all of what is left of a receipt through the gateway,
+// issuing an adjustment linked to it
+func (r *Receipts) Refund(action parking.Action, gateway payment.Gateway, now time.Time) (*parking.Adjustment, error) {
+	if action.ReceiptNumber == nil {
+		return nil, parking.ErrReceiptNotFound
+	}
+	receipt, ok := r.receipts[*action.ReceiptNumber]
+	if !ok {
+		return nil, parking.NewError(parking.ErrReceiptNotFound, "", "", "receipt "+*action.ReceiptNumber)
+	}
+	if action.OperatorID == "" || action.Reason == 0 {
+		return nil, parking.NewError(parking.ErrInvalidAdjustment, "", "", "operator & reason are required")
+	}
+	left := receipt.Fees - receipt.Refunded
+	amount := action.Amount
+	if amount == 0 {
+		amount = left
+	}
+	if amount == 0 || amount > left {
+		return nil, parking.NewError(parking.ErrInvalidAdjustment, "", "", fmt.Sprintf("%d of %d left to refund on receipt %s", left, receipt.Fees, receipt.ReceiptNumber))
+	}
+	if gateway == nil {
+		return nil, parking.NewError(parking.ErrPaymentFailed, "", "", "no payment gateway")
+	}
+	if err := gateway.Refund(receipt.PaymentID, amount); err != nil {
+		return nil, parking.NewError(parking.ErrPaymentFailed, "", "", err.Error())
+	}
+	receipt.Refunded += amount
+	if receipt.Refunded == receipt.Fees {
+		receipt.PaymentStatus = parking.PaymentStatus_Refunded
+	}
+	r.adjustmentNo++
+	adjustment := parking.Adjustment{
+		AdjustmentNumber: fmt.Sprintf(fmt.Sprintf("A-%%0%dd", r.PadWidth), r.adjustmentNo),
+		ReceiptNumber:    receipt.ReceiptNumber,
+		Amount:           amount,
+		Reason:           action.Reason,
+		OperatorID:       action.OperatorID,
+		DateTime:         now,
+	}
+	r.adjustments = append(r.adjustments, adjustment)
+	return &adjustment, r.record(Entry{LotID: r.LotID, Adjustment: &adjustment})
+}
+
+func (r *Receipts) record(entry Entry) error {
+	if r.Ledger == nil {
+		return nil
+	}
+	return r.Ledger.Record(entry)
+}
+
+// sequence returns the sequence of a receipt or adjustment number, 0 if it has none
+func sequence(number, prefix string) uint {
+	seq, err := strconv.ParseUint(strings.TrimPrefix(number, prefix), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return uint(seq)
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceipts_Refund(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     parking.Action
+		want       uint
+		wantErr    error
+		wantStatus parking.PaymentStatus
+	}{
+		{
+			name:       "part of the receipt should be refunded",
+			action:     parking.Action{ReceiptNumber: ToStringPtr("R-001"), Amount: 40, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"},
+			want:       40,
+			wantStatus: parking.PaymentStatus_Settled,
+		},
+		{
+			name:       "all of the receipt should be refunded without an amount",
+			action:     parking.Action{ReceiptNumber: ToStringPtr("R-001"), Reason: parking.AdjustmentReason_BarrierFailure, OperatorID: "op-1"},
+			want:       100,
+			wantStatus: parking.PaymentStatus_Refunded,
+		},
+		{
+			name:    "refund can not exceed the receipt",
+			action:  parking.Action{ReceiptNumber: ToStringPtr("R-001"), Amount: 101, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"},
+			wantErr: parking.ErrInvalidAdjustment,
+		},
+		{
+			name:    "refund needs an operator",
+			action:  parking.Action{ReceiptNumber: ToStringPtr("R-001"), Amount: 40, Reason: parking.AdjustmentReason_Overcharge},
+			wantErr: parking.ErrInvalidAdjustment,
+		},
+		{
+			name:    "unknown receipt can not be refunded",
+			action:  parking.Action{ReceiptNumber: ToStringPtr("R-009"), Amount: 40, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"},
+			wantErr: parking.ErrReceiptNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := payment.NewCash()
+			authorizationID, _ := gateway.Authorize(100, "")
+			paymentID, _ := gateway.Capture(authorizationID)
+			r := &Receipts{PadWidth: 3}
+			r.Add(parking.Receipt{ReceiptNumber: "R-001", Fees: 100, PaymentStatus: parking.PaymentStatus_Settled, PaymentID: paymentID})
+
+			got, err := r.Refund(tt.action, gateway, time.Now())
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, "A-001", got.AdjustmentNumber, "AdjustmentNumber must match")
+			assert.Equal(t, "R-001", got.ReceiptNumber, "adjustment must be linked to the receipt")
+			assert.Equal(t, tt.want, got.Amount, "Amount must match")
+			receipt, _ := r.Get("R-001")
+			assert.Equal(t, tt.want, receipt.Refunded, "Refunded must match")
+			assert.Equal(t, tt.wantStatus, receipt.PaymentStatus, "PaymentStatus must match")
+			assert.Equal(t, []parking.Adjustment{*got}, r.Adjustments("R-001"), "Adjustments must match")
+		})
+	}
+}
+
+func TestReceipts_Issue(t *testing.T) {
+	ledger := NewLedger(nil)
+	r := &Receipts{LotID: "mall-1", PadWidth: 3, Ledger: ledger}
+	entry, err := r.IssueEntry(parking.Ticket{TicketNumber: "001", VehicleType: parking.VehicleType_CarSuv, Fees: 20}, "cash-1")
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, "R-001", entry.ReceiptNumber, "ReceiptNumber must match")
+	assert.Equal(t, parking.PaymentStatus_Settled, entry.PaymentStatus, "entry receipt must be settled")
+	assert.Equal(t, []parking.LineItem{{Description: "Parking fee", Amount: 20}}, entry.LineItems, "LineItems must match")
+
+	exit := &parking.Receipt{VehicleType: parking.VehicleType_CarSuv, Fees: 40, PaymentStatus: parking.PaymentStatus_Pending}
+	assert.Nil(t, r.Issue(exit), "Err must be nil")
+	assert.Equal(t, "R-002", exit.ReceiptNumber, "receipts must be numbered in turn")
+	assert.Equal(t, parking.PaymentStatus_Settled, exit.PaymentStatus, "issued receipt must be settled")
+	assert.Len(t, ledger.Entries(), 2, "issued receipts must be recorded")
+}
+
+func TestNewReceipts(t *testing.T) {
+	gateway := payment.NewCash()
+	authorizationID, _ := gateway.Authorize(60, "")
+	paymentID, _ := gateway.Capture(authorizationID)
+	ledger := NewLedger(nil)
+	for _, entry := range []Entry{
+		{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-001", Fees: 40, PaymentStatus: parking.PaymentStatus_Settled}},
+		{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-002", Fees: 60, PaymentStatus: parking.PaymentStatus_Settled, PaymentID: paymentID}},
+		{LotID: "mall-1", Adjustment: &parking.Adjustment{AdjustmentNumber: "A-001", ReceiptNumber: "R-001", Amount: 40}},
+		{LotID: "mall-2", Receipt: &parking.Receipt{ReceiptNumber: "R-007", Fees: 30, PaymentStatus: parking.PaymentStatus_Settled}},
+	} {
+		assert.Nil(t, ledger.Record(entry), "Err must be nil")
+	}
+	r := NewReceipts("mall-1", 3, ledger)
+
+	refunded, _ := r.Get("R-001")
+	assert.Equal(t, parking.PaymentStatus_Refunded, refunded.PaymentStatus, "receipt refunded in full must be carried on as refunded")
+	assert.Len(t, r.Adjustments("R-001"), 1, "Adjustments must be carried on")
+	_, ok := r.Get("R-007")
+	assert.False(t, ok, "receipts of other lots must not be carried on")
+
+	receipt := &parking.Receipt{Fees: 20}
+	assert.Nil(t, r.Issue(receipt), "Err must be nil")
+	assert.Equal(t, "R-003", receipt.ReceiptNumber, "numbering must carry on from the ledger")
+	adjustment, err := r.Refund(parking.Action{ReceiptNumber: ToStringPtr("R-002"), Amount: 10, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"}, gateway, time.Now())
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, "A-002", adjustment.AdjustmentNumber, "numbering must carry on from the ledger")
+}
+
+func TestNewReceipts_RefundAfterRestart(t *testing.T) {
+	ledger := NewLedger(nil)
+	before := &Receipts{LotID: "mall-1", PadWidth: 3, Ledger: ledger}
+	gateway := payment.NewCash()
+	authorizationID, _ := gateway.Authorize(10, "")
+	paymentID, _ := gateway.Capture(authorizationID)
+	assert.Nil(t, before.Issue(&parking.Receipt{Fees: 10, PaymentID: paymentID}), "Err must be nil")
+
+	// after a restart the gateway no longer holds the payments of the earlier run
+	restarted := payment.NewCash()
+	r := NewReceipts("mall-1", 3, ledger)
+	authorizationID, _ = restarted.Authorize(100, "")
+	paymentID, _ = restarted.Capture(authorizationID)
+	receipt := &parking.Receipt{Fees: 100, PaymentID: paymentID}
+	assert.Nil(t, r.Issue(receipt), "Err must be nil")
+
+	_, err := r.Refund(parking.Action{ReceiptNumber: ToStringPtr("R-001"), Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"}, restarted, time.Now())
+	assert.ErrorIs(t, err, parking.ErrPaymentFailed, "payment of the earlier run must not be refunded")
+	adjustment, err := r.Refund(parking.Action{ReceiptNumber: &receipt.ReceiptNumber, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"}, restarted, time.Now())
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, uint(100), adjustment.Amount, "receipt of this run must be refunded in full")
+}
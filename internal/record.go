@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"time"
+)
+
+// Record represents a vehicle parked against a ticket
+type Record struct {
+	VehicleType       parking.VehicleType
+	Plate             string
+	SpotNumber        uint
+	EntryDateTime     time.Time
+	ExitDateTime      *time.Time // set once the vehicle has exited
+	ReservationNumber string     // set when the vehicle arrived against a reservation
+	PassID            string     // set when the vehicle parked with a pass
+	Event             string     // set when the vehicle entered during an event
+	Paid              uint       // paid on entry, taken off the fees on exit
+	PaymentID         string     // payment of the amount paid on entry
+	Surge             float64    // multiplier of the fees locked at entry, none if 0
+	Overstayed        bool       // set once the overstay was emitted
+}
+
+// Records holds the records of parked vehicles keyed by ticket number. Records are moved out once
+// their vehicle exits, only their ticket numbers are kept, so the parked vehicles alone are scanned
+type Records struct {
+	parked map[string]*Record
+	exited map[string]bool
+}
+
+// NewRecords creates the Records holding the records given, those with an exit time as exited
+func NewRecords(records map[string]*Record) Records {
+	r := Records{parked: map[string]*Record{}, exited: map[string]bool{}}
+	for ticketNo, rec := range records {
+		r.parked[ticketNo] = rec
+		if rec.ExitDateTime != nil {
+			r.Exit(ticketNo, *rec.ExitDateTime)
+		}
+	}
+	return r
+}
+
+// Add records the vehicle parked against the ticket number
+func (r Records) Add(ticketNo string, rec *Record) {
+	r.parked[ticketNo] = rec
+}
+
+// Get returns the record of the vehicle parked against the ticket number
+func (r Records) Get(ticketNo string) (*Record, bool) {
+	rec, ok := r.parked[ticketNo]
+	return rec, ok
+}
+
+// Parked returns the records of the vehicles parked keyed by ticket number
+func (r Records) Parked() map[string]*Record {
+	return r.parked
+}
+
+// Exit moves the record of the vehicle out once it exited at the time
+func (r Records) Exit(ticketNo string, at time.Time) {
+	if rec, ok := r.parked[ticketNo]; ok {
+		rec.ExitDateTime = &at
+		delete(r.parked, ticketNo)
+		r.exited[ticketNo] = true
+	}
+}
+
+// Ticket returns the ticket the vehicle was issued
+func (rec *Record) Ticket(ticketNo string) parking.Ticket {
+	return parking.Ticket{
+		TicketNumber:      ticketNo,
+		VehicleType:       rec.VehicleType,
+		Plate:             rec.Plate,
+		SpotNumber:        rec.SpotNumber,
+		EntryDateTime:     rec.EntryDateTime,
+		ReservationNumber: rec.ReservationNumber,
+		PassID:            rec.PassID,
+		Event:             rec.Event,
+		Fees:              rec.Paid,
+		Surge:             rec.Surge,
+	}
+}
+
+// Lookup finds the record of a parked vehicle against the ticket number,
+// telling apart an unknown ticket, an exited ticket and a vehicle mismatch
+func (r Records) Lookup(ticketNo string, vehicleType parking.VehicleType) (*Record, error) {
+	rec, ok := r.parked[ticketNo]
+	if r.exited[ticketNo] {
+		return nil, &parking.TicketExitedError{TicketNumber: ticketNo}
+	}
+	if !ok {
+		return nil, &parking.TicketNotFoundError{TicketNumber: ticketNo}
+	}
+	if rec.VehicleType != vehicleType {
+		return nil, &parking.VehicleMismatchError{
+			TicketNumber: ticketNo,
+			Parked:       rec.VehicleType,
+			Presented:    vehicleType,
+		}
+	}
+	return rec, nil
+}
+
+// Occupied returns number of vehicles of given type currently parked
+func (r Records) Occupied(vehicleType parking.VehicleType) uint {
+	var occupied uint
+	for _, rec := range r.parked {
+		if rec.VehicleType == vehicleType {
+			occupied++
+		}
+	}
+	return occupied
+}
+
+// FreeSpot returns the lowest spot number not taken by a parked vehicle of given type,
+// 0 if all the spots are taken
+func (r Records) FreeSpot(vehicleType parking.VehicleType, total uint) uint {
+	taken := map[uint]bool{}
+	for _, rec := range r.parked {
+		if rec.VehicleType == vehicleType {
+			taken[rec.SpotNumber] = true
+		}
+	}
+	for spot := uint(1); spot <= total; spot++ {
+		if !taken[spot] {
+			return spot
+		}
+	}
+	return 0
+}
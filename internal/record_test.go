@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"errors"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecords_Lookup(t *testing.T) {
+	exitTime := Now()
+	records := NewRecords(map[string]*Record{
+		"001": {
+			VehicleType:   parking.VehicleType_Motorcycle,
+			SpotNumber:    1,
+			EntryDateTime: Now().Add(-1 * time.Hour),
+		},
+		"002": {
+			VehicleType:   parking.VehicleType_CarSuv,
+			SpotNumber:    1,
+			EntryDateTime: Now().Add(-1 * time.Hour),
+			ExitDateTime:  &exitTime,
+		},
+	})
+	type args struct {
+		ticketNo    string
+		vehicleType parking.VehicleType
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     *Record
+		wantErr  error
+		sentinel error
+	}{
+		{
+			name: "parked vehicle should be found",
+			args: args{
+				ticketNo:    "001",
+				vehicleType: parking.VehicleType_Motorcycle,
+			},
+			want: records.Parked()["001"],
+		},
+		{
+			name: "unknown ticket should not be found",
+			args: args{
+				ticketNo:    "003",
+				vehicleType: parking.VehicleType_Motorcycle,
+			},
+			wantErr:  &parking.TicketNotFoundError{TicketNumber: "003"},
+			sentinel: parking.ErrInvalidTicket,
+		},
+		{
+			name: "exited ticket can not be used again",
+			args: args{
+				ticketNo:    "002",
+				vehicleType: parking.VehicleType_CarSuv,
+			},
+			wantErr:  &parking.TicketExitedError{TicketNumber: "002"},
+			sentinel: parking.ErrInvalidTicket,
+		},
+		{
+			name: "ticket can not be used by another vehicle type",
+			args: args{
+				ticketNo:    "001",
+				vehicleType: parking.VehicleType_CarSuv,
+			},
+			wantErr: &parking.VehicleMismatchError{
+				TicketNumber: "001",
+				Parked:       parking.VehicleType_Motorcycle,
+				Presented:    parking.VehicleType_CarSuv,
+			},
+			sentinel: parking.ErrVehicleMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := records.Lookup(tt.args.ticketNo, tt.args.vehicleType)
+			assert.Equal(t, tt.want, got, "Record must match, want %v, got %v", tt.want, got)
+			assert.Equal(t, tt.wantErr, err, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.sentinel != nil {
+				assert.True(t, errors.Is(err, tt.sentinel), "Err must wrap %v", tt.sentinel)
+			}
+		})
+	}
+}
+
+func TestRecords_Occupied(t *testing.T) {
+	exitTime := Now()
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 1},
+		"002": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 2, ExitDateTime: &exitTime},
+		"003": {VehicleType: parking.VehicleType_CarSuv, SpotNumber: 1},
+		"004": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 3},
+	})
+	tests := []struct {
+		name        string
+		vehicleType parking.VehicleType
+		want        uint
+	}{
+		{
+			name:        "exited vehicles should not be counted",
+			vehicleType: parking.VehicleType_Motorcycle,
+			want:        2,
+		},
+		{
+			name:        "only vehicles of given type should be counted",
+			vehicleType: parking.VehicleType_CarSuv,
+			want:        1,
+		},
+		{
+			name:        "no vehicles of given type parked",
+			vehicleType: parking.VehicleType_BusTruck,
+			want:        0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := records.Occupied(tt.vehicleType)
+			assert.Equal(t, tt.want, got, "Occupied must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestRecords_FreeSpot(t *testing.T) {
+	exitTime := Now()
+	records := NewRecords(map[string]*Record{
+		"001": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 1},
+		"002": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 2, ExitDateTime: &exitTime},
+		"003": {VehicleType: parking.VehicleType_Motorcycle, SpotNumber: 3},
+	})
+	tests := []struct {
+		name        string
+		vehicleType parking.VehicleType
+		total       uint
+		want        uint
+	}{
+		{
+			name:        "spot vacated by an exited vehicle should be reused",
+			vehicleType: parking.VehicleType_Motorcycle,
+			total:       3,
+			want:        2,
+		},
+		{
+			name:        "spots are numbered per vehicle type",
+			vehicleType: parking.VehicleType_CarSuv,
+			total:       3,
+			want:        1,
+		},
+		{
+			name:        "no spot when all are taken",
+			vehicleType: parking.VehicleType_Motorcycle,
+			total:       1,
+			want:        0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := records.FreeSpot(tt.vehicleType, tt.total)
+			assert.Equal(t, tt.want, got, "FreeSpot must match, want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestRecords_Exit(t *testing.T) {
+	records := NewRecords(nil)
+	records.Add("001", &Record{VehicleType: parking.VehicleType_CarSuv, SpotNumber: 1, EntryDateTime: Now()})
+	records.Add("002", &Record{VehicleType: parking.VehicleType_CarSuv, SpotNumber: 2, EntryDateTime: Now()})
+	exitTime := Now().Add(time.Hour)
+	records.Exit("001", exitTime)
+
+	assert.Len(t, records.Parked(), 1, "exited record must be moved out")
+	assert.Equal(t, uint(1), records.Occupied(parking.VehicleType_CarSuv), "Occupied must match")
+	assert.Equal(t, uint(1), records.FreeSpot(parking.VehicleType_CarSuv, 2), "spot of the exited vehicle must be free")
+	_, err := records.Lookup("001", parking.VehicleType_CarSuv)
+	assert.IsType(t, &parking.TicketExitedError{}, err, "exited ticket must be told apart")
+}
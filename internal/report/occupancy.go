@@ -0,0 +1,165 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sahaj/pkg/parking"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sample is the occupancy of a vehicle type at a Parking Lot at an instant
+type Sample struct {
+	LotID       string              `json:"lotId"`
+	At          time.Time           `json:"at"`
+	VehicleType parking.VehicleType `json:"vehicleType"`
+	Occupied    uint                `json:"occupied"`
+	Total       uint                `json:"total"`
+	Utilisation float64             `json:"utilisation"` // percent of the spots occupied
+}
+
+// DailyPeak is the peak occupancy of a vehicle type at a Parking Lot over a day,
+// and its average utilisation over the samples of the day
+type DailyPeak struct {
+	LotID              string              `json:"lotId"`
+	Date               string              `json:"date"`
+	VehicleType        parking.VehicleType `json:"vehicleType"`
+	Peak               Sample              `json:"peak"` // first time the peak was reached
+	AverageUtilisation float64             `json:"averageUtilisation"`
+}
+
+// Occupancy reports the occupancy of Parking Lots over time
+type Occupancy struct {
+	Interval time.Duration `json:"interval"`
+	Samples  []Sample      `json:"samples"` // every interval, from the first change till the last one
+	Days     []DailyPeak   `json:"days"`
+}
+
+// BuildOccupancy samples the occupancy changes every interval, by Parking Lot & vehicle type,
+// and finds the daily peaks from the changes themselves
+func BuildOccupancy(changes []parking.OccupancyChange, interval time.Duration) (Occupancy, error) {
+	if interval <= 0 {
+		return Occupancy{}, errors.New("interval must be positive")
+	}
+	type key struct {
+		lotID       string
+		vehicleType parking.VehicleType
+	}
+	series := map[key][]parking.OccupancyChange{}
+	var keys []key
+	for _, change := range changes {
+		k := key{change.LotID, change.VehicleType}
+		if _, ok := series[k]; !ok {
+			keys = append(keys, k)
+		}
+		series[k] = append(series[k], change)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].lotID != keys[j].lotID {
+			return keys[i].lotID < keys[j].lotID
+		}
+		return keys[i].vehicleType < keys[j].vehicleType
+	})
+
+	report := Occupancy{Interval: interval}
+	for _, k := range keys {
+		changes := series[k]
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].At.Before(changes[j].At) })
+
+		days := map[string]*DailyPeak{}
+		var dates []string
+		day := func(t time.Time) *DailyPeak {
+			date := t.Format("2006-01-02")
+			d, ok := days[date]
+			if !ok {
+				d = &DailyPeak{LotID: k.lotID, Date: date, VehicleType: k.vehicleType}
+				days[date] = d
+				dates = append(dates, date)
+			}
+			return d
+		}
+		for _, change := range changes {
+			day(change.At).peak(sample(change, change.At))
+		}
+
+		// every sample is the occupancy as of the last change at or before it
+		samples := map[string]int{}
+		next := 0
+		first, last := changes[0].At.Truncate(interval), changes[len(changes)-1].At
+		for at := first; !at.After(last); at = at.Add(interval) {
+			for next < len(changes) && !changes[next].At.After(at) {
+				next++
+			}
+			s := Sample{LotID: k.lotID, At: at, VehicleType: k.vehicleType, Total: changes[0].Total}
+			if next > 0 {
+				s = sample(changes[next-1], at)
+			}
+			report.Samples = append(report.Samples, s)
+			d := day(at)
+			d.peak(s) // occupancy carried over from the day before
+			d.AverageUtilisation += s.Utilisation
+			samples[d.Date]++
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			d := days[date]
+			if samples[date] > 0 {
+				d.AverageUtilisation /= float64(samples[date])
+			}
+			report.Days = append(report.Days, *d)
+		}
+	}
+	return report, nil
+}
+
+// peak keeps the sample if higher than the peak so far, or as high but earlier
+func (d *DailyPeak) peak(s Sample) {
+	if d.Peak.At.IsZero() || s.Occupied > d.Peak.Occupied || s.Occupied == d.Peak.Occupied && s.At.Before(d.Peak.At) {
+		d.Peak = s
+	}
+}
+
+func sample(change parking.OccupancyChange, at time.Time) Sample {
+	s := Sample{LotID: change.LotID, At: at, VehicleType: change.VehicleType, Occupied: change.Occupied, Total: change.Total}
+	if change.Total > 0 {
+		s.Utilisation = float64(change.Occupied) * 100 / float64(change.Total)
+	}
+	return s
+}
+
+// WriteJSON exports the report as JSON
+func (o Occupancy) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(o)
+}
+
+// WriteCSV exports the report as CSV, a row per sample then a peak & an average row per day
+func (o Occupancy) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"section", "lotId", "time", "vehicleType", "occupied", "total", "utilisation"}}
+	for _, s := range o.Samples {
+		rows = append(rows, sampleRow("sample", s.At.Format(time.RFC3339), s))
+	}
+	for _, d := range o.Days {
+		rows = append(rows,
+			sampleRow("peak", d.Peak.At.Format(time.RFC3339), d.Peak),
+			[]string{"average", d.LotID, d.Date, d.VehicleType.String(), "", "", percent(d.AverageUtilisation)},
+		)
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func sampleRow(section, at string, s Sample) []string {
+	return []string{section, s.LotID, at, s.VehicleType.String(), strconv.FormatUint(uint64(s.Occupied), 10), strconv.FormatUint(uint64(s.Total), 10), percent(s.Utilisation)}
+}
+
+func percent(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bytes"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func changes() []parking.OccupancyChange {
+	day := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	return []parking.OccupancyChange{
+		{LotID: "mall-1", At: day.Add(10 * time.Minute), VehicleType: parking.VehicleType_CarSuv, Occupied: 1, Total: 4},
+		{LotID: "mall-1", At: day.Add(40 * time.Minute), VehicleType: parking.VehicleType_CarSuv, Occupied: 2, Total: 4},
+		{LotID: "mall-1", At: day.Add(70 * time.Minute), VehicleType: parking.VehicleType_CarSuv, Occupied: 1, Total: 4},
+		{LotID: "mall-1", At: day.Add(24 * time.Hour), VehicleType: parking.VehicleType_CarSuv, Occupied: 0, Total: 4},
+	}
+}
+
+func TestBuildOccupancy(t *testing.T) {
+	day := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		interval    time.Duration
+		wantSamples []Sample
+		wantDays    []DailyPeak
+		wantErr     bool
+	}{
+		{
+			name:     "samples every half hour",
+			interval: 30 * time.Minute,
+			wantDays: []DailyPeak{
+				{
+					LotID: "mall-1", Date: "2022-07-01", VehicleType: parking.VehicleType_CarSuv,
+					Peak: Sample{LotID: "mall-1", At: day.Add(40 * time.Minute), VehicleType: parking.VehicleType_CarSuv, Occupied: 2, Total: 4, Utilisation: 50},
+					// 0, 25, 50, then 25 till midnight
+					AverageUtilisation: (0 + 25 + 50 + 25*25) / 28.0,
+				},
+				{
+					LotID: "mall-1", Date: "2022-07-02", VehicleType: parking.VehicleType_CarSuv,
+					// 25 carried over from midnight till 10:00
+					Peak:               Sample{LotID: "mall-1", At: time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC), VehicleType: parking.VehicleType_CarSuv, Occupied: 1, Total: 4, Utilisation: 25},
+					AverageUtilisation: 25 * 20 / 21.0,
+				},
+			},
+		},
+		{
+			name:     "samples every hour",
+			interval: time.Hour,
+			wantSamples: []Sample{
+				{LotID: "mall-1", At: day, VehicleType: parking.VehicleType_CarSuv, Total: 4},
+				{LotID: "mall-1", At: day.Add(time.Hour), VehicleType: parking.VehicleType_CarSuv, Occupied: 2, Total: 4, Utilisation: 50},
+				{LotID: "mall-1", At: day.Add(2 * time.Hour), VehicleType: parking.VehicleType_CarSuv, Occupied: 1, Total: 4, Utilisation: 25},
+			},
+		},
+		{
+			name:     "invalid interval",
+			interval: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildOccupancy(changes(), tt.interval)
+			if tt.wantErr {
+				assert.Error(t, err, "BuildOccupancy must fail")
+				return
+			}
+			assert.NoError(t, err, "BuildOccupancy must not fail")
+			if tt.wantSamples != nil {
+				assert.Equal(t, tt.wantSamples, got.Samples[:len(tt.wantSamples)], "Samples must match")
+			}
+			if tt.wantDays != nil {
+				assert.Len(t, got.Days, len(tt.wantDays), "Days must match")
+				for i, want := range tt.wantDays {
+					assert.Equal(t, want.Peak, got.Days[i].Peak, "Peak must match")
+					assert.InDelta(t, want.AverageUtilisation, got.Days[i].AverageUtilisation, 0.001, "AverageUtilisation must match")
+				}
+			}
+		})
+	}
+}
+
+func TestOccupancy_WriteCSV(t *testing.T) {
+	r, err := BuildOccupancy(changes()[:2], time.Hour)
+	assert.NoError(t, err, "BuildOccupancy must not fail")
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteCSV(&buf), "WriteCSV must not fail")
+	assert.Equal(t, `section,lotId,time,vehicleType,occupied,total,utilisation
+sample,mall-1,2022-07-01T10:00:00Z,Car/Suv,0,4,0.00
+peak,mall-1,2022-07-01T10:40:00Z,Car/Suv,2,4,50.00
+average,mall-1,2022-07-01,Car/Suv,,,0.00
+`, buf.String(), "CSV must match")
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sahaj/internal"
+	"sahaj/pkg/parking"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Periods revenue can be reported by
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// Line totals the receipts of a group, revenue is net of refunds. Prepaid is what was paid upfront,
+// on entry or with the reservation, for the stays exited, it counts in revenue on the receipt it was paid with
+type Line struct {
+	Key      string `json:"key"`
+	Receipts int    `json:"receipts"`
+	Revenue  uint   `json:"revenue"`
+	Refunded uint   `json:"refunded"`
+	Prepaid  uint   `json:"prepaid"`
+}
+
+// Report totals the receipts of a Ledger
+type Report struct {
+	Period             string  `json:"period"`
+	Total              Line    `json:"total"`
+	AverageStayMinutes float64 `json:"averageStayMinutes"` // of receipts issued on exit
+	ByPeriod           []Line  `json:"byPeriod"`
+	ByVehicle          []Line  `json:"byVehicle"`
+	ByBand             []Line  `json:"byBand"`
+}
+
+// Build reports the receipts of the ledger entries by period, vehicle type & tariff band,
+// receipts are dated when issued, refunds count against their receipt. The average stay is of exits only
+func Build(entries []internal.Entry, period string) (Report, error) {
+	if period != Day && period != Week && period != Month {
+		return Report{}, fmt.Errorf("invalid period %q, must be one of %s, %s or %s", period, Day, Week, Month)
+	}
+	type key struct{ lotID, receiptNo string }
+	refunded := map[key]uint{}
+	for _, entry := range entries {
+		if entry.Adjustment != nil {
+			refunded[key{entry.LotID, entry.Adjustment.ReceiptNumber}] += entry.Adjustment.Amount
+		}
+	}
+
+	report := Report{Period: period, Total: Line{Key: "total"}}
+	byPeriod, byVehicle, byBand := map[string]*Line{}, map[string]*Line{}, map[string]*Line{}
+	var stays time.Duration
+	var exits int
+	for _, entry := range entries {
+		receipt := entry.Receipt
+		if receipt == nil {
+			continue
+		}
+		refund := refunded[key{entry.LotID, receipt.ReceiptNumber}]
+		// receipts of a prepaid reservation carry its window, not a stay
+		exit := receipt.ReservationNumber == "" && !receipt.ExitDateTime.IsZero()
+		if exit {
+			stays += receipt.ExitDateTime.Sub(receipt.EntryDateTime)
+			exits++
+		}
+		issued := issuedAt(*receipt)
+		band := receipt.Band
+		switch {
+		case receipt.ReservationNumber != "":
+			band = "prepaid reservation"
+		case band == "":
+			band = "on entry"
+		}
+		for _, line := range []*Line{&report.Total, group(byPeriod, periodKey(issued, period)), group(byVehicle, receipt.VehicleType.String()), group(byBand, band)} {
+			add(line, receipt.Fees, refund)
+			line.Prepaid += receipt.Prepaid
+		}
+	}
+	if exits > 0 {
+		report.AverageStayMinutes = (stays / time.Duration(exits)).Minutes()
+	}
+	report.ByPeriod = sorted(byPeriod)
+	report.ByVehicle = sorted(byVehicle)
+	report.ByBand = sorted(byBand)
+	return report, nil
+}
+
+// WriteJSON exports the report as JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(r)
+}
+
+// WriteCSV exports the report as CSV, a row per line of every section
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{
+		{"section", "key", "receipts", "revenue", "refunded", "prepaid"},
+		row("total", r.Total),
+		{"averageStayMinutes", "", "", strconv.FormatFloat(r.AverageStayMinutes, 'f', 2, 64), "", ""},
+	}
+	for _, section := range []struct {
+		name  string
+		lines []Line
+	}{{r.Period, r.ByPeriod}, {"vehicle", r.ByVehicle}, {"band", r.ByBand}} {
+		for _, line := range section.lines {
+			rows = append(rows, row(section.name, line))
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func row(section string, line Line) []string {
+	return []string{section, line.Key, strconv.Itoa(line.Receipts), strconv.FormatUint(uint64(line.Revenue), 10), strconv.FormatUint(uint64(line.Refunded), 10), strconv.FormatUint(uint64(line.Prepaid), 10)}
+}
+
+// issuedAt returns when the receipt was settled, its exit or entry for receipts recorded without it
+func issuedAt(receipt parking.Receipt) time.Time {
+	switch {
+	case !receipt.IssuedAt.IsZero():
+		return receipt.IssuedAt
+	case !receipt.ExitDateTime.IsZero():
+		return receipt.ExitDateTime
+	default:
+		return receipt.EntryDateTime
+	}
+}
+
+func add(line *Line, fees, refunded uint) {
+	if refunded > fees {
+		refunded = fees
+	}
+	line.Receipts++
+	line.Revenue += fees - refunded
+	line.Refunded += refunded
+}
+
+func group(lines map[string]*Line, key string) *Line {
+	line, ok := lines[key]
+	if !ok {
+		line = &Line{Key: key}
+		lines[key] = line
+	}
+	return line
+}
+
+func sorted(lines map[string]*Line) []Line {
+	out := make([]Line, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, *line)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// periodKey labels the day as 2006-01-02, the ISO week as 2006-W01 or the month as 2006-01
+func periodKey(t time.Time, period string) string {
+	switch period {
+	case Week:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Month:
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
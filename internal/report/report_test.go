@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bytes"
+	"sahaj/internal"
+	"sahaj/pkg/parking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func entries() []internal.Entry {
+	day := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	return []internal.Entry{
+		{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-001", VehicleType: parking.VehicleType_CarSuv, Band: "0-4h", EntryDateTime: day, ExitDateTime: day.Add(2 * time.Hour), Fees: 60}},
+		{LotID: "mall-1", Receipt: &parking.Receipt{ReceiptNumber: "R-002", VehicleType: parking.VehicleType_Motorcycle, Band: "4-12h", EntryDateTime: day, ExitDateTime: day.Add(6 * time.Hour), Fees: 40, Prepaid: 20}},
+		{LotID: "mall-1", Adjustment: &parking.Adjustment{ReceiptNumber: "R-001", Amount: 20}},
+		{LotID: "mall-2", Receipt: &parking.Receipt{ReceiptNumber: "R-001", VehicleType: parking.VehicleType_CarSuv, EntryDateTime: day.Add(72 * time.Hour), Fees: 30}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name         string
+		period       string
+		wantByPeriod []Line
+		wantErr      bool
+	}{
+		{
+			name:   "revenue by day",
+			period: Day,
+			wantByPeriod: []Line{
+				{Key: "2022-07-01", Receipts: 2, Revenue: 80, Refunded: 20, Prepaid: 20},
+				{Key: "2022-07-04", Receipts: 1, Revenue: 30},
+			},
+		},
+		{
+			name:   "revenue by ISO week",
+			period: Week,
+			wantByPeriod: []Line{
+				{Key: "2022-W26", Receipts: 2, Revenue: 80, Refunded: 20, Prepaid: 20},
+				{Key: "2022-W27", Receipts: 1, Revenue: 30},
+			},
+		},
+		{
+			name:   "revenue by month",
+			period: Month,
+			wantByPeriod: []Line{
+				{Key: "2022-07", Receipts: 3, Revenue: 110, Refunded: 20, Prepaid: 20},
+			},
+		},
+		{
+			name:    "invalid period",
+			period:  "year",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(entries(), tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantByPeriod, got.ByPeriod, "ByPeriod must match")
+			assert.Equal(t, Line{Key: "total", Receipts: 3, Revenue: 110, Refunded: 20, Prepaid: 20}, got.Total, "Total must match")
+			assert.Equal(t, []Line{
+				{Key: "Car/Suv", Receipts: 2, Revenue: 70, Refunded: 20},
+				{Key: "Motorcycle", Receipts: 1, Revenue: 40, Prepaid: 20},
+			}, got.ByVehicle, "ByVehicle must match")
+			assert.Equal(t, []Line{
+				{Key: "0-4h", Receipts: 1, Revenue: 40, Refunded: 20},
+				{Key: "4-12h", Receipts: 1, Revenue: 40, Prepaid: 20},
+				{Key: "on entry", Receipts: 1, Revenue: 30},
+			}, got.ByBand, "ByBand must match")
+			assert.Equal(t, float64(240), got.AverageStayMinutes, "AverageStayMinutes must match")
+		})
+	}
+}
+
+func TestReport_WriteCSV(t *testing.T) {
+	r, err := Build(entries(), Month)
+	assert.Nil(t, err, "Err must be nil")
+	var buf bytes.Buffer
+	assert.Nil(t, r.WriteCSV(&buf), "Err must be nil")
+	assert.Equal(t, `section,key,receipts,revenue,refunded,prepaid
+total,total,3,110,20,20
+averageStayMinutes,,,240.00,,
+month,2022-07,3,110,20,20
+vehicle,Car/Suv,2,70,20,0
+vehicle,Motorcycle,1,40,0,20
+band,0-4h,1,40,20,0
+band,4-12h,1,40,0,20
+band,on entry,1,30,0,0
+`, buf.String(), "CSV must match")
+}
+
+func TestBuild_PrepaidReservation(t *testing.T) {
+	paid := time.Date(2022, 1, 10, 9, 0, 0, 0, time.UTC)
+	from := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []internal.Entry{
+		{LotID: "airport-1", Receipt: &parking.Receipt{ReceiptNumber: "R-001", ReservationNumber: "RES-001", VehicleType: parking.VehicleType_CarSuv, EntryDateTime: from, ExitDateTime: from.Add(240 * time.Hour), IssuedAt: paid, Fees: 500}},
+		{LotID: "airport-1", Receipt: &parking.Receipt{ReceiptNumber: "R-002", VehicleType: parking.VehicleType_CarSuv, Band: "0-1d", EntryDateTime: from, ExitDateTime: from.Add(2 * time.Hour), IssuedAt: from.Add(2 * time.Hour), Fees: 0, Prepaid: 500}},
+	}
+	got, err := Build(entries, Month)
+	assert.Nil(t, err, "Err must be nil")
+	assert.Equal(t, []Line{
+		{Key: "2022-01", Receipts: 1, Revenue: 500},
+		{Key: "2022-03", Receipts: 1, Prepaid: 500},
+	}, got.ByPeriod, "reservation must be dated when paid")
+	assert.Equal(t, []Line{
+		{Key: "0-1d", Receipts: 1, Prepaid: 500},
+		{Key: "prepaid reservation", Receipts: 1, Revenue: 500},
+	}, got.ByBand, "ByBand must match")
+	assert.Equal(t, float64(120), got.AverageStayMinutes, "reservation window must be left out of the average stay")
+}
@@ -1,94 +1,209 @@
 package stadium
 
 import (
-	"errors"
 	"fmt"
 	"sahaj/internal"
 	"sahaj/pkg/parking"
 	"sort"
-	"strings"
 	"time"
 )
 
 type ParkingLot struct {
-	parking   internal.Parking     // base parking model
-	record    map[string]time.Time // parking record
-	receiptNo uint                 // tracks upcoming receipt
-	padWidth  uint                 // for printing receipt & ticket number
+	parking  internal.Parking  // base parking model
+	record   internal.Records  // parking record
+	booking  internal.Booking  // reservations
+	receipts internal.Receipts // settled receipts & their adjustments
+	ticketNo uint              // tracks upcoming ticket
+	padWidth uint              // for printing receipt & ticket number
 }
 
-func New(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) *ParkingLot {
-	// Bus/Truck are not allowed at Stadium
-	if _, ok := inventory[parking.VehicleType_BusTruck]; ok {
-		panic(errors.New(parking.VehicleType_BusTruck.String() + " can not be parked @ " + parking.ModelType_Stadium.String()))
+func New(id string, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory, opts ...internal.Option) (*ParkingLot, error) {
+	base, err := internal.NewParking(id, parking.ModelType_Stadium, fee, inventory, opts...)
+	if err != nil {
+		return nil, err
 	}
 	return &ParkingLot{
-		parking: internal.Parking{
-			Inventory: inventory,
-			Fee:       fee,
+		parking: base,
+		record:  internal.NewRecords(nil),
+		booking: internal.Booking{
+			ReleaseAfter: base.ReservationRelease,
+			EarlyArrival: base.EarlyArrival,
+			HoldAhead:    base.ReservationHold,
+			PadWidth:     4,
 		},
-		record:    map[string]time.Time{},
-		receiptNo: 0,
-		padWidth:  4,
+		receipts: internal.NewReceipts(id, 4, base.Ledger),
+		ticketNo: 0,
+		padWidth: 4,
+	}, nil
+}
+
+func (p *ParkingLot) GetID() string {
+	return p.parking.ID
+}
+
+// Schedule adds an event with a flat fee on entry to the Parking Lot
+func (p *ParkingLot) Schedule(event parking.Event) error {
+	if p.parking.Events == nil {
+		p.parking.Events = &internal.Events{}
 	}
+	return p.parking.WrapError(parking.Action{}, p.parking.Events.Schedule(event))
 }
 
 func (p *ParkingLot) GetType() parking.ModelType {
 	return parking.ModelType_Stadium
 }
 
+// Occupancy takes a snapshot of the spots of the Parking Lot
+func (p *ParkingLot) Occupancy() parking.Occupancy {
+	return p.parking.Occupancy(p.record, &p.booking, p.parking.Now())
+}
+
+// OccupancyChanges returns the occupancy of the Parking Lot every time a vehicle parked or exited
+func (p *ParkingLot) OccupancyChanges() []parking.OccupancyChange {
+	return p.parking.OccupancyChanges()
+}
+
+// Ticket returns the ticket of a vehicle still parked
+func (p *ParkingLot) Ticket(ticketNo string) (parking.Ticket, error) {
+	return p.parking.Ticket(p.record, ticketNo)
+}
+
+// Tickets lists the tickets of vehicles still parked matching the query
+func (p *ParkingLot) Tickets(query parking.TicketQuery) parking.TicketPage {
+	return p.parking.Tickets(p.record, query)
+}
+
 func (p *ParkingLot) Do(action parking.Action) parking.Result {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
 	res := parking.Result{}
 	switch action.ActionType {
 	case parking.ActionType_Park:
 		res.ParkingTicket, res.Err = p.generateParkingTicket(action)
+		// the flat fee of an event is collected on entry whatever the payment timing
+		if res.Err == nil && (p.parking.PaymentTiming == parking.PaymentTiming_OnEntry || res.ParkingTicket.Event != "") {
+			res.ParkingReceipt, res.Err = p.generateEntryReceipt(res.ParkingTicket)
+		}
 	case parking.ActionType_UnPark:
 		res.ParkingReceipt, res.Err = p.generateParkingReceipt(action)
+	case parking.ActionType_Reserve:
+		if action.Prepay {
+			res.Err = parking.NewError(parking.ErrChargeNotSupported, p.parking.ID, "", "prepaid reservations are only supported @ "+parking.ModelType_Airport.String())
+			break
+		}
+		res.Reservation, res.Err = p.booking.Reserve(action, p.parking.Inventory, p.parking.Now(), 0)
+	case parking.ActionType_CancelReservation:
+		res.Reservation, res.Err = p.booking.Cancel(action, p.parking.Now())
+	case parking.ActionType_ModifyReservation:
+		res.Reservation, res.Err = p.booking.Modify(action, p.parking.Inventory, p.parking.Now())
+	case parking.ActionType_Refund:
+		res.Adjustment, res.Err = p.receipts.Refund(action, p.parking.Gateway, p.parking.Now())
 	default:
 		res.Err = parking.ErrInvalidAction
 	}
+	res.Err = p.parking.WrapError(action, res.Err)
+	p.parking.LogResult(action, res)
+	p.parking.EmitResult(action, res, p.record, p.parking.Now())
 	return res
 }
 
+// CheckOverstays emits the vehicles parked longer than the maximum stay by now, for a sweep
+// to report them while no action is done at the Parking Lot
+func (p *ParkingLot) CheckOverstays() {
+	p.parking.EmitOverstays(p.record, p.parking.Now())
+}
+
+// SetFee changes the tariff of the Parking Lot, stays in progress are charged as per it on exit
+func (p *ParkingLot) SetFee(fee parking.Fee) {
+	p.parking.SetFee(fee, p.parking.Now())
+}
+
 func (p *ParkingLot) generateParkingTicket(action parking.Action) (*parking.Ticket, error) {
 	inv, ok := p.parking.Inventory[action.VehicleType]
 	if !ok {
-		return nil, parking.ErrVehicleNotAllowed
+		return nil, parking.NewError(parking.ErrVehicleNotAllowed, p.parking.ID, "", action.VehicleType.String()+" has no spots")
 	}
-	if len(p.record) < int(inv.Total) {
-		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), len(p.record)+1)
-		key := getRecordKey(tktNo, action.VehicleType)
-		p.record[key] = time.Now()
-		return &parking.Ticket{
-			TicketNumber:  tktNo,
-			SpotNumber:    uint(len(p.record)),
-			EntryDateTime: time.Now(),
-		}, nil
+	now := p.parking.Now()
+	var reservationNo string
+	if action.ReservationNumber != nil {
+		reservationNo = *action.ReservationNumber
+		if err := p.booking.Open(reservationNo, action.VehicleType, now); err != nil {
+			return nil, err
+		}
+	}
+	// spots held for reservations can only be taken by their own vehicle
+	occupied := p.record.Occupied(action.VehicleType) + p.booking.Held(action.VehicleType, now, reservationNo)
+	if occupied < inv.Total {
+		passID, err := p.parking.UsePass(action, now)
+		if err != nil {
+			return nil, err
+		}
+		var eventName string
+		var paid uint
+		// vehicles entering during an event pay its flat fee on entry, unless covered by a pass
+		if event, ok := p.parking.EventAt(now); ok && passID == "" {
+			if eventFee, ok := event.Fees[action.VehicleType]; ok {
+				eventName = event.Name
+				paid = eventFee
+			}
+		}
+		if eventName == "" && p.parking.PaymentTiming == parking.PaymentTiming_OnEntry && passID == "" {
+			paid, err = calculateFee(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: action.VehicleType}, p.parking.Fee, now, now.Add(internal.EntryStay(p.parking.Fee, action.VehicleType)))
+			if err != nil {
+				return nil, err
+			}
+		}
+		paymentID, err := p.parking.Settle(action, paid)
+		if err != nil {
+			return nil, err
+		}
+		p.ticketNo++
+		tktNo := fmt.Sprintf(fmt.Sprintf("%%0%dd", p.padWidth), p.ticketNo)
+		rec := &internal.Record{
+			VehicleType:       action.VehicleType,
+			Plate:             action.Plate,
+			SpotNumber:        p.record.FreeSpot(action.VehicleType, inv.Total),
+			EntryDateTime:     now,
+			ReservationNumber: reservationNo,
+			PassID:            passID,
+			Event:             eventName,
+			Paid:              paid,
+			PaymentID:         paymentID,
+		}
+		p.record.Add(tktNo, rec)
+		p.parking.RecordOccupancy(p.record, action.VehicleType, now)
+		p.booking.Arrive(reservationNo)
+		ticket := rec.Ticket(tktNo)
+		return &ticket, nil
 	}
-	return nil, parking.ErrNoSpace
+	return nil, parking.NewError(parking.ErrNoSpace, p.parking.ID, "", fmt.Sprintf("%d of %d %s spots occupied or reserved", occupied, inv.Total, action.VehicleType))
+}
+
+// generateEntryReceipt issues the receipt of the amount paid on entry
+func (p *ParkingLot) generateEntryReceipt(ticket *parking.Ticket) (*parking.Receipt, error) {
+	rec, _ := p.record.Get(ticket.TicketNumber)
+	return p.receipts.IssueEntry(*ticket, rec.PaymentID)
 }
 
 func (p *ParkingLot) generateParkingReceipt(action parking.Action) (*parking.Receipt, error) {
 	if action.TicketNumer == nil {
 		return nil, parking.ErrInvalidTicket
 	}
-	key := getRecordKey(*action.TicketNumer, action.VehicleType)
-	if parkingTime, ok := p.record[key]; ok {
-		fee, err := calculateFee(action, p.parking.Fee, parkingTime, time.Now())
-		if err != nil {
-			return nil, err
-		}
-		p.receiptNo++
-		receipt := &parking.Receipt{
-			ReceiptNumber: fmt.Sprintf(fmt.Sprintf("R-%%0%dd", p.padWidth), p.receiptNo),
-			EntryDateTime: parkingTime,
-			ExitDateTime:  time.Now(),
-			Fees:          fee,
-		}
-		delete(p.record, key)
-		return receipt, nil
+	rec, err := p.record.Lookup(*action.TicketNumer, action.VehicleType)
+	if err != nil {
+		return nil, err
+	}
+	exitTime := p.parking.Now()
+	// stay is covered by the pass till it expires, only the stay past it is charged
+	entryTime, _ := p.parking.ChargedFrom(rec)
+	if rec.Event != "" {
+		// the flat fee paid on entry covers all of the stay
+		entryTime = exitTime
 	}
-	return nil, parking.ErrVehicleMismatch
+	charge, err := p.parking.Charge(calculateFee, action, entryTime, exitTime)
+	if err != nil {
+		return nil, err
+	}
+	return p.parking.Checkout(action, p.record, &p.receipts, *action.TicketNumer, charge)
 }
 
 func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime time.Time) (uint, error) {
@@ -109,24 +224,20 @@ func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime ti
 				break
 			}
 		}
-		if len(rates) == 0 {
+		base, calendar := internal.SplitRates(rates)
+		if len(base) == 0 {
 			return fees, parking.ErrInvalidTicket
 		}
 
 		// make sure the rates are in increasing order of from time
-		ratesSortedByTime := parking.SortRatesByStartTime(rates)
+		ratesSortedByTime := parking.SortRatesByStartTime(base)
 		sort.Sort(ratesSortedByTime)
 
-		hr := uint(parkingDuration.Hours())
-
 		allRates := []int{}
 		allHours := []int{}
 		for _, rate := range ratesSortedByTime {
 			allRates = append(allRates, int(rate.Rate))
 			allHours = append(allHours, int(rate.Till))
-			if rate.From <= hr {
-				fees += rate.Rate
-			}
 		}
 
 		sort.Ints(allRates)
@@ -134,20 +245,38 @@ func calculateFee(action parking.Action, fee parking.Fee, entryTime, exitTime ti
 		sort.Ints(allHours)
 		maxHour := uint(allHours[len(allHours)-1])
 
-		if hr >= maxHour {
-			fees = fees + (hr-maxHour)*maxRate
+		// calendar rates of an interval replace its base rate when the calendar matches the interval start
+		intervalRates := func(from uint) []parking.Rate {
+			var matching []parking.Rate
+			for _, rate := range calendar {
+				if rate.From == from {
+					matching = append(matching, rate)
+				}
+			}
+			return matching
 		}
+		lastInterval := ratesSortedByTime[len(ratesSortedByTime)-1]
+
+		fees = internal.CapDaily(fee.DailyCap, parkingDuration, func(stay time.Duration) uint {
+			var charge uint
+			hr := uint(internal.RoundUp(stay, fee.RoundingMinutes).Hours())
+			for _, rate := range ratesSortedByTime {
+				if rate.From <= hr {
+					start := entryTime.Add(time.Duration(rate.From) * time.Hour)
+					charge += internal.RateAt(intervalRates(rate.From), rate, fee.Holidays, start).Rate
+				}
+			}
+			// every hour past the last interval is billed on its own
+			for h := maxHour; h < hr; h++ {
+				start := entryTime.Add(time.Duration(h) * time.Hour)
+				charge += internal.RateAt(intervalRates(lastInterval.From), parking.Rate{Rate: maxRate}, fee.Holidays, start).Rate
+			}
+			return charge
+		})
 	case parking.ChargeType_PerDay:
 		fallthrough
 	default:
 		return fees, parking.ErrChargeNotSupported
 	}
-	return fees, nil
-}
-
-func getRecordKey(ticketNo string, vehicleType parking.VehicleType) string {
-	var key strings.Builder
-	key.WriteString(ticketNo)
-	key.WriteString(vehicleType.String())
-	return key.String()
+	return internal.ApplyLimits(fee, parkingDuration, fees), nil
 }
@@ -3,6 +3,7 @@ package stadium
 import (
 	"sahaj/internal"
 	"sahaj/pkg/parking"
+	"sahaj/pkg/payment"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 
 func TestNew(t *testing.T) {
 	type args struct {
+		id        string
 		fee       parking.Fee
 		inventory map[parking.VehicleType]internal.Inventory
 	}
@@ -39,7 +41,7 @@ func TestNew(t *testing.T) {
 			},
 		},
 		{
-			name: "trying to instantiate Airport Parking Lot with Bus/Truck as Vehicle should panic",
+			name: "trying to instantiate Airport Parking Lot with Bus/Truck as Vehicle should fail",
 			args: args{
 				fee: parking.Fee{
 					Charge:   parking.ChargeType_PerDay,
@@ -56,19 +58,29 @@ func TestNew(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.want != nil {
-				got := New(tt.args.fee, tt.args.inventory)
-				assert.Equal(t, len(tt.want.parking.Inventory), len(tt.want.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(tt.want.parking.Inventory))
-				assert.Equal(t, tt.want.parking.Fee.Charge, got.parking.Fee.Charge, "ChargeType must match, expected %v got %v", tt.want.parking.Fee.Charge, got.parking.Fee.Charge)
-				assert.Equal(t, len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles), "supported vehicle quantity must match, expected %v got %v", len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles))
-			} else {
-				bool := assert.Panics(t, func() { New(tt.args.fee, tt.args.inventory) }, "must panic")
-				assert.True(t, bool, "New() should have panicked")
+			got, err := New(tt.args.id, tt.args.fee, tt.args.inventory)
+			if tt.want == nil {
+				assert.Nil(t, got, "New() must not create Parking Lot")
+				assert.ErrorIs(t, err, parking.ErrVehicleNotAllowed, "New() must fail with %v, got %v", parking.ErrVehicleNotAllowed, err)
+				return
 			}
+			assert.Nil(t, err, "Err must be nil")
+			assert.Equal(t, len(tt.want.parking.Inventory), len(got.parking.Inventory), "supported inventory quantity must match, expected %v got %v", len(tt.want.parking.Inventory), len(got.parking.Inventory))
+			assert.Equal(t, tt.want.parking.Fee.Charge, got.parking.Fee.Charge, "ChargeType must match, expected %v got %v", tt.want.parking.Fee.Charge, got.parking.Fee.Charge)
+			assert.Equal(t, len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles), "supported vehicle quantity must match, expected %v got %v", len(tt.want.parking.Fee.Vehicles), len(got.parking.Fee.Vehicles))
 		})
 	}
 }
 
+// newParkingLot creates a Parking Lot for test cases, panics on invalid inventory
+func newParkingLot(fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) ParkingLot {
+	p, err := New("", fee, inventory)
+	if err != nil {
+		panic(err)
+	}
+	return *p
+}
+
 func TestParkingLot_GetType(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -77,7 +89,7 @@ func TestParkingLot_GetType(t *testing.T) {
 	}{
 		{
 			name:   "Type of Parking Lot should always be Airport",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{}),
 			want:   parking.ModelType_Stadium,
 		},
 	}
@@ -101,7 +113,7 @@ func TestParkingLot_Do(t *testing.T) {
 	}{
 		{
 			name: "Motercycle should get parked",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -124,7 +136,7 @@ func TestParkingLot_Do(t *testing.T) {
 		},
 		{
 			name: "Motercycle should not get parked if no space in Parking lot",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 0,
 				},
@@ -160,11 +172,15 @@ func TestParkingLot_Do(t *testing.T) {
 						},
 					},
 				},
-				record: map[string]time.Time{
-					"001Motorcycle": internal.Now().Add(-55 * time.Minute),
-				},
-				receiptNo: 0,
-				padWidth:  3,
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-55 * time.Minute),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
 			},
 			args: args{
 				action: parking.Action{
@@ -186,7 +202,7 @@ func TestParkingLot_Do(t *testing.T) {
 		},
 		{
 			name: "Invalid parking Action",
-			fields: *New(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+			fields: newParkingLot(parking.Fee{}, map[parking.VehicleType]internal.Inventory{
 				parking.VehicleType_Motorcycle: {
 					Total: 1,
 				},
@@ -221,11 +237,15 @@ func TestParkingLot_Do(t *testing.T) {
 						},
 					},
 				},
-				record: map[string]time.Time{
-					"001Motorcycle": internal.Now().Add(-55 * time.Minute),
-				},
-				receiptNo: 0,
-				padWidth:  3,
+				record: internal.NewRecords(map[string]*internal.Record{
+					"001": {
+						VehicleType:   parking.VehicleType_Motorcycle,
+						SpotNumber:    1,
+						EntryDateTime: internal.Now().Add(-55 * time.Minute),
+					},
+				}),
+				receipts: internal.Receipts{PadWidth: 3},
+				padWidth: 3,
 			},
 			args: args{
 				action: parking.Action{
@@ -257,7 +277,7 @@ func TestParkingLot_Do(t *testing.T) {
 				assert.Equal(t, tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber, "ReceiptNumber must match, want %v, got %v", tt.want.ParkingReceipt.ReceiptNumber, got.ParkingReceipt.ReceiptNumber)
 				assert.Equal(t, tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees, "Fees must match, want %v, got %v", tt.want.ParkingReceipt.Fees, got.ParkingReceipt.Fees)
 			}
-			assert.Equal(t, tt.want.Err, got.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
+			assert.ErrorIs(t, got.Err, tt.want.Err, "Err must match, want %v, got %v", tt.want.Err, got.Err)
 		})
 	}
 }
@@ -351,6 +371,92 @@ func Test_calculateFee(t *testing.T) {
 			want:    390,
 			wantErr: false,
 		},
+		{
+			name: "Motorcycle parked for 30 hours, capped at 300 a day. Fees: 600",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_Motorcycle,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_Motorcycle,
+							Rates: []parking.Rate{
+								{
+									From: 0,
+									Till: 4,
+									Rate: 30,
+								},
+								{
+									From: 4,
+									Till: 12,
+									Rate: 60,
+								},
+								{
+									From: 12,
+									Till: 0,
+									Rate: 100,
+								},
+							},
+						},
+					},
+					DailyCap: 300,
+				},
+				entryTime: internal.Now(),
+				exitTime:  internal.Now().Add(30 * time.Hour),
+			},
+			want:    600,
+			wantErr: false,
+		},
+		{
+			name: "Motorcycle parked for 3 hours on an event day. Fees: 50",
+			args: args{
+				action: parking.Action{
+					ActionType:  parking.ActionType_UnPark,
+					VehicleType: parking.VehicleType_Motorcycle,
+				},
+				fee: parking.Fee{
+					Charge: parking.ChargeType_PerHour,
+					Vehicles: []parking.Vehicle{
+						{
+							Kind: parking.VehicleType_Motorcycle,
+							Rates: []parking.Rate{
+								{
+									From: 0,
+									Till: 4,
+									Rate: 30,
+								},
+								{
+									From: 0,
+									Till: 4,
+									Rate: 50,
+									When: &parking.Calendar{
+										Holidays: true,
+									},
+								},
+								{
+									From: 4,
+									Till: 12,
+									Rate: 60,
+								},
+								{
+									From: 12,
+									Till: 0,
+									Rate: 100,
+								},
+							},
+						},
+					},
+					Holidays: []string{"2022-07-04"},
+				},
+				entryTime: time.Date(2022, 7, 4, 10, 0, 0, 0, time.UTC),
+				exitTime:  time.Date(2022, 7, 4, 13, 0, 0, 0, time.UTC),
+			},
+			want:    50,
+			wantErr: false,
+		},
 		{
 			name: "Electric SUV parked for 11 hours and 30 mins. Fees: 180",
 			args: args{
@@ -482,30 +588,154 @@ func Test_calculateFee(t *testing.T) {
 	}
 }
 
-func Test_getRecordKey(t *testing.T) {
-	type args struct {
-		ticketNo    string
-		vehicleType parking.VehicleType
-	}
+func TestParkingLot_Do_Event(t *testing.T) {
+	events, err := internal.NewEvents(parking.Event{
+		Name:  "Final",
+		Start: time.Now().Add(-time.Hour),
+		End:   time.Now().Add(3 * time.Hour),
+		Fees: map[parking.VehicleType]uint{
+			parking.VehicleType_CarSuv: 250,
+		},
+	})
+	assert.Nil(t, err, "Err must be nil")
+	lot, err := New("stadium-1", parking.Fee{
+		Charge: parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind:  parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{{From: 0, Till: 4, Rate: 60}},
+			},
+			{
+				Kind:  parking.VehicleType_Motorcycle,
+				Rates: []parking.Rate{{From: 0, Till: 4, Rate: 30}},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv:     {Total: 1},
+		parking.VehicleType_Motorcycle: {Total: 1},
+	}, internal.WithEvents(events))
+	assert.Nil(t, err, "Err must be nil")
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, "Final", got.ParkingTicket.Event, "Event must match")
+	assert.Equal(t, uint(250), got.ParkingTicket.Fees, "event fee must be paid on entry")
+	assert.Equal(t, "R-0001", got.ParkingReceipt.ReceiptNumber, "receipt must be issued for the event fee")
+	assert.Equal(t, uint(250), got.ParkingReceipt.Fees, "receipt must have the event fee")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &got.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(0), got.ParkingReceipt.Fees, "stay must be covered by the event fee")
+	assert.Equal(t, uint(250), got.ParkingReceipt.Prepaid, "Prepaid must be the event fee")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Refund, ReceiptNumber: internal.ToStringPtr("R-0001"), Reason: parking.AdjustmentReason_BarrierFailure, OperatorID: "op-1"})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(250), got.Adjustment.Amount, "event fee must be refunded")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, "", got.ParkingTicket.Event, "vehicle without event fee must pay regular fees")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_Motorcycle, TicketNumer: &got.ParkingTicket.TicketNumber})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, uint(30), got.ParkingReceipt.Fees, "Fees must be the regular fees")
+
+	err = lot.Schedule(parking.Event{Name: "Semi Final", Start: time.Now().Add(2 * time.Hour), End: time.Now().Add(5 * time.Hour)})
+	assert.ErrorIs(t, err, parking.ErrEventWindow, "overlapping event must be rejected")
+}
+
+func TestParkingLot_Do_PayOnEntry(t *testing.T) {
+	lot, err := New("stadium-1", parking.Fee{
+		Charge: parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind: parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{
+					{From: 0, Till: 4, Rate: 60},
+					{From: 4, Till: 12, Rate: 120},
+				},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {Total: 2},
+	}, internal.WithPaymentTiming(parking.PaymentTiming_OnEntry))
+	assert.Nil(t, err, "Err must be nil")
+
 	tests := []struct {
-		name string
-		args args
-		want string
+		name      string
+		parked    time.Duration
+		wantItems []parking.LineItem
+		wantFees  uint
 	}{
 		{
-			name: "001Motorcycle",
-			args: args{
-				ticketNo:    "001",
-				vehicleType: parking.VehicleType_Motorcycle,
+			name:   "exit within the first interval only validates the ticket",
+			parked: time.Hour,
+			wantItems: []parking.LineItem{
+				{Description: "Parking fee", Amount: 60},
+				{Description: "Paid on entry", Amount: -60},
+			},
+			wantFees: 0,
+		},
+		{
+			name:   "exit past the first interval charges the overstay",
+			parked: 5 * time.Hour,
+			wantItems: []parking.LineItem{
+				{Description: "Parking fee", Amount: 180},
+				{Description: "Paid on entry", Amount: -60},
 			},
-			want: "001Motorcycle",
+			wantFees: 120,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getRecordKey(tt.args.ticketNo, tt.args.vehicleType); got != tt.want {
-				t.Errorf("getRecordKey() = %v, want %v", got, tt.want)
-			}
+			got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+			assert.Nil(t, got.Err, "Err must be nil")
+			assert.Equal(t, uint(60), got.ParkingTicket.Fees, "first interval must be paid on entry")
+			assert.Equal(t, uint(60), got.ParkingReceipt.Fees, "receipt must be issued on entry")
+			ticketNo := got.ParkingTicket.TicketNumber
+			rec, _ := lot.record.Get(ticketNo)
+			rec.EntryDateTime = time.Now().Add(-tt.parked)
+
+			got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &ticketNo})
+			assert.Nil(t, got.Err, "Err must be nil")
+			assert.Equal(t, tt.wantItems, got.ParkingReceipt.LineItems, "LineItems must match")
+			assert.Equal(t, tt.wantFees, got.ParkingReceipt.Fees, "Fees must match")
+			assert.Equal(t, uint(60), got.ParkingReceipt.Prepaid, "Prepaid must be the amount paid on entry")
 		})
 	}
 }
+
+func TestParkingLot_Do_Payment(t *testing.T) {
+	lot, err := New("stadium-1", parking.Fee{
+		Charge: parking.ChargeType_PerHour,
+		Vehicles: []parking.Vehicle{
+			{
+				Kind:  parking.VehicleType_CarSuv,
+				Rates: []parking.Rate{{From: 0, Till: 4, Rate: 60}},
+			},
+		},
+	}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_CarSuv: {Total: 1},
+	}, internal.WithGateway(payment.NewFakeCard("4000")))
+	assert.Nil(t, err, "Err must be nil")
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_CarSuv})
+	assert.Nil(t, got.Err, "Err must be nil")
+	ticketNo := got.ParkingTicket.TicketNumber
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &ticketNo, PaymentSource: "4000"})
+	assert.ErrorIs(t, got.Err, parking.ErrPaymentFailed, "declined card must block the exit")
+	assert.Equal(t, parking.PaymentStatus_Pending, got.ParkingReceipt.PaymentStatus, "receipt must be pending")
+	assert.Equal(t, uint(60), got.ParkingReceipt.Fees, "Fees must match")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_UnPark, VehicleType: parking.VehicleType_CarSuv, TicketNumer: &ticketNo, PaymentSource: "4242"})
+	assert.Nil(t, got.Err, "vehicle must exit once paid")
+	assert.Equal(t, parking.PaymentStatus_Settled, got.ParkingReceipt.PaymentStatus, "receipt must be settled")
+	assert.Equal(t, "R-0001", got.ParkingReceipt.ReceiptNumber, "receipt must be numbered once settled")
+	assert.NotEmpty(t, got.ParkingReceipt.PaymentID, "PaymentID must be set")
+
+	got = lot.Do(parking.Action{ActionType: parking.ActionType_Refund, ReceiptNumber: &got.ParkingReceipt.ReceiptNumber, Amount: 20, Reason: parking.AdjustmentReason_Overcharge, OperatorID: "op-1"})
+	assert.Nil(t, got.Err, "Err must be nil")
+	assert.Equal(t, "A-0001", got.Adjustment.AdjustmentNumber, "AdjustmentNumber must match")
+	assert.Equal(t, uint(20), got.Adjustment.Amount, "Amount must match")
+}
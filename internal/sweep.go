@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sync"
+	"time"
+)
+
+// SweepOverstays checks the Parking Lots for overstaying vehicles at every interval, so a quiet lot
+// reports them without waiting for an action. Parking Lots are not safe for concurrent use, the sweep
+// holds mu, the lock guarding their actions, while checking. The returned func stops the sweep,
+// there is none unless the interval is positive
+func SweepOverstays(mu sync.Locker, every time.Duration, lots ...parking.ParkingLot) (stop func()) {
+	if every <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(every)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				for _, lot := range lots {
+					lot.CheckOverstays()
+				}
+				mu.Unlock()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
+}
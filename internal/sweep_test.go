@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"sahaj/pkg/parking"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sweptLot counts the overstay checks of the sweep
+type sweptLot struct {
+	parking.ParkingLot
+	mu     sync.Mutex
+	checks int
+}
+
+func (l *sweptLot) CheckOverstays() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checks++
+}
+
+func (l *sweptLot) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.checks
+}
+
+func TestSweepOverstays(t *testing.T) {
+	var mu sync.Mutex
+	lots := []*sweptLot{{}, {}}
+	stop := SweepOverstays(&mu, time.Millisecond, lots[0], lots[1])
+	assert.Eventually(t, func() bool { return lots[0].count() > 1 && lots[1].count() > 1 }, time.Second, time.Millisecond, "lots must be checked at every interval")
+	stop()
+	stop()
+	checks := lots[0].count()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, checks, lots[0].count(), "lots must not be checked once stopped")
+}
+
+func TestSweepOverstays_Disabled(t *testing.T) {
+	var mu sync.Mutex
+	lot := &sweptLot{}
+	stop := SweepOverstays(&mu, 0, lot)
+	time.Sleep(5 * time.Millisecond)
+	stop()
+	assert.Equal(t, 0, lot.count(), "lots must not be checked without a positive interval")
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sahaj/pkg/parking"
+	"sync"
+	"time"
+)
+
+// Headers of the webhook requests
+const (
+	HeaderSignature = "X-Parking-Signature" // sha256=<hex HMAC-SHA256 of the body keyed by the secret>
+	HeaderEventType = "X-Parking-Event"
+	HeaderEventID   = "X-Parking-Event-ID"
+)
+
+// Subscription posts the domain events a partner is interested in to its URL
+type Subscription struct {
+	ID     string                    `json:"id"`
+	URL    string                    `json:"url"`
+	Secret string                    `json:"-"`      // signs the requests, unsigned if empty
+	Events []parking.DomainEventType `json:"events"` // event types posted, all if empty
+	Lots   []string                  `json:"lots"`   // IDs of Parking Lots posted, all if empty
+}
+
+// Matches tells if the event is posted to the subscription
+func (s Subscription) Matches(event parking.DomainEvent) bool {
+	return (len(s.Events) == 0 || containsType(s.Events, event.Type)) && (len(s.Lots) == 0 || containsLot(s.Lots, event.LotID))
+}
+
+// DeadLetter is a line of the dead-letter file, an event a subscription gave up on
+type DeadLetter struct {
+	SubscriptionID string              `json:"subscriptionId"`
+	URL            string              `json:"url"`
+	Event          parking.DomainEvent `json:"event"`
+	Attempts       int                 `json:"attempts"`
+	Error          string              `json:"error"`
+	At             time.Time           `json:"at"`
+}
+
+// Webhooks posts the domain events to the matching subscriptions as JSON, retrying failed
+// requests with exponential backoff. Events a subscription still failed are appended to
+// the dead-letter writer as JSON lines, if any. Retries block, wrap it in an Async
+// not to hold up the Parking Lot
+type Webhooks struct {
+	Client   *http.Client
+	Attempts int           // requests per event & subscription, at least one
+	Backoff  time.Duration // wait before the first retry, doubled on every retry
+
+	mu            sync.Mutex // guards the dead-letter writer
+	deadLetter    io.Writer
+	subscriptions []Subscription
+	sleep         func(time.Duration)
+}
+
+// NewWebhooks creates a Dispatcher posting to the subscriptions, 3 attempts a second apart
+// then 2 seconds apart by default
+func NewWebhooks(deadLetter io.Writer, subscriptions ...Subscription) *Webhooks {
+	return &Webhooks{
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		Attempts:      3,
+		Backoff:       time.Second,
+		deadLetter:    deadLetter,
+		subscriptions: subscriptions,
+		sleep:         time.Sleep,
+	}
+}
+
+// Dispatch posts the event to every matching subscription. An event given up on is delivered
+// once dead-lettered, the first one that could not be dead-lettered is returned for it to be retried
+func (w *Webhooks) Dispatch(event parking.DomainEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, subscription := range w.subscriptions {
+		if !subscription.Matches(event) {
+			continue
+		}
+		attempts, err := w.post(subscription, event, body)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("webhook %s gave up on event %s after %d attempts: %w", subscription.ID, event.ID, attempts, err)
+		dlErr := w.bury(DeadLetter{
+			SubscriptionID: subscription.ID,
+			URL:            subscription.URL,
+			Event:          event,
+			Attempts:       attempts,
+			Error:          err.Error(),
+			At:             time.Now(),
+		})
+		if dlErr != nil && first == nil {
+			first = fmt.Errorf("%v, not dead-lettered: %w", err, dlErr)
+		}
+	}
+	return first
+}
+
+// post sends the event till it is accepted or the attempts run out
+func (w *Webhooks) post(subscription Subscription, event parking.DomainEvent, body []byte) (int, error) {
+	attempts := w.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := w.Backoff
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			w.sleep(backoff)
+			backoff *= 2
+		}
+		if err = w.send(subscription, event, body); err == nil {
+			return attempt, nil
+		}
+	}
+	return attempts, err
+}
+
+func (w *Webhooks) send(subscription Subscription, event parking.DomainEvent, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventType, event.Type.String())
+	req.Header.Set(HeaderEventID, event.ID)
+	if subscription.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(subscription.Secret, body))
+	}
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *Webhooks) bury(letter DeadLetter) error {
+	if w.deadLetter == nil {
+		return errors.New("no dead-letter writer")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return json.NewEncoder(w.deadLetter).Encode(letter)
+}
+
+// Sign returns the signature of a webhook body, receivers compare it to the signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func containsType(types []parking.DomainEventType, t parking.DomainEventType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLot(lots []string, lotID string) bool {
+	for _, v := range lots {
+		if v == lotID {
+			return true
+		}
+	}
+	return false
+}
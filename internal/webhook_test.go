@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sahaj/pkg/parking"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// receiver is a local webhook endpoint failing the first fail requests
+type receiver struct {
+	mu         sync.Mutex
+	fail       int
+	requests   int
+	signatures []string
+	events     []parking.DomainEvent
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if r.fail > 0 {
+		r.fail--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	var event parking.DomainEvent
+	_ = json.Unmarshal(body, &event)
+	r.signatures = append(r.signatures, req.Header.Get(HeaderSignature))
+	r.events = append(r.events, event)
+	if req.Header.Get(HeaderSignature) != Sign("secret", body) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+}
+
+func TestWebhooks_Dispatch(t *testing.T) {
+	full := parking.DomainEvent{ID: "1", Type: parking.DomainEventType_LotFull, LotID: "mall-1", VehicleType: parking.VehicleType_CarSuv}
+	tests := []struct {
+		name         string
+		subscription Subscription
+		fail         int
+		event        parking.DomainEvent
+		wantRequests int
+		wantEvents   int
+		wantWaits    []time.Duration
+		deadLetter   io.Writer // the buffer dead letters are read from if nil
+		buried       bool
+		wantErr      bool
+	}{
+		{
+			name:         "matching event should be posted signed",
+			subscription: Subscription{ID: "s-1", Secret: "secret", Events: []parking.DomainEventType{parking.DomainEventType_LotFull}},
+			event:        full,
+			wantRequests: 1,
+			wantEvents:   1,
+		},
+		{
+			name:         "event of another type should not be posted",
+			subscription: Subscription{ID: "s-1", Secret: "secret", Events: []parking.DomainEventType{parking.DomainEventType_VehicleOverstayed}},
+			event:        full,
+		},
+		{
+			name:         "event of another lot should not be posted",
+			subscription: Subscription{ID: "s-1", Secret: "secret", Lots: []string{"airport-1"}},
+			event:        full,
+		},
+		{
+			name:         "failed request should be retried with backoff",
+			subscription: Subscription{ID: "s-1", Secret: "secret"},
+			fail:         2,
+			event:        full,
+			wantRequests: 3,
+			wantEvents:   1,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "event should be dead-lettered once attempts run out",
+			subscription: Subscription{ID: "s-1", Secret: "secret"},
+			fail:         3,
+			event:        full,
+			wantRequests: 3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+			buried:       true,
+		},
+		{
+			name:         "wrongly signed event should be dead-lettered",
+			subscription: Subscription{ID: "s-1", Secret: "wrong"},
+			event:        full,
+			wantRequests: 3,
+			wantEvents:   3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+			buried:       true,
+		},
+		{
+			name:         "event that could not be dead-lettered should fail",
+			subscription: Subscription{ID: "s-1", Secret: "secret"},
+			fail:         3,
+			event:        full,
+			wantRequests: 3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+			deadLetter:   failingWriter{},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &receiver{fail: tt.fail}
+			server := httptest.NewServer(r)
+			defer server.Close()
+
+			var deadLetter bytes.Buffer
+			tt.subscription.URL = server.URL
+			webhooks := NewWebhooks(&deadLetter, tt.subscription)
+			if tt.deadLetter != nil {
+				webhooks = NewWebhooks(tt.deadLetter, tt.subscription)
+			}
+			var waits []time.Duration
+			webhooks.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			err := webhooks.Dispatch(tt.event)
+			assert.Equal(t, tt.wantErr, err != nil, "Err must match")
+			assert.Equal(t, tt.wantRequests, r.requests, "requests must match")
+			assert.Len(t, r.events, tt.wantEvents, "events must match")
+			assert.Equal(t, tt.wantWaits, waits, "backoff must match")
+			if tt.wantEvents > 0 {
+				assert.Equal(t, tt.event, r.events[0], "event must match")
+			}
+			if tt.buried {
+				var letter DeadLetter
+				assert.NoError(t, json.Unmarshal(deadLetter.Bytes(), &letter), "dead letter must be JSON")
+				assert.Equal(t, "s-1", letter.SubscriptionID, "SubscriptionID must match")
+				assert.Equal(t, tt.event, letter.Event, "Event must match")
+				assert.Equal(t, 3, letter.Attempts, "Attempts must match")
+			} else {
+				assert.Empty(t, deadLetter.String(), "nothing must be dead-lettered")
+			}
+		})
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13", Sign("secret", []byte("{}")), "signature must match")
+}
@@ -1,46 +1,113 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"sahaj/internal"
+	"sahaj/internal/audit"
 	"sahaj/pkg/parking"
 	parkingFactory "sahaj/pkg/parking_factory"
 )
 
+// ledgerFile is where receipts are recorded, for reports
+const ledgerFile = "ledger.jsonl"
+
+// timelineFile is where occupancy changes are recorded, for reports
+const timelineFile = "occupancy.jsonl"
+
+// auditFile is where every action is recorded, for compliance
+const auditFile = "audit.jsonl"
+
+// newLogger logs to stderr from the level set by LOG_LEVEL (debug, info, warn or error), info by default,
+// as JSON if LOG_FORMAT is json
+func newLogger() *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+	return internal.NewLogger(os.Stderr, level, os.Getenv("LOG_FORMAT") == "json")
+}
+
+// fatal logs the failure and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err.Error())
+	os.Exit(1)
+}
+
 func main() {
+	logger := newLogger()
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReport(os.Args[2:], os.Stdout); err != nil {
+			fatal(logger, "report failed", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:], logger); err != nil {
+			fatal(logger, "serve failed", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:], os.Stdout); err != nil {
+			fatal(logger, "verify failed", err)
+		}
+		return
+	}
+
 	f, err := os.Open("sample.json")
 	if err != nil {
-		log.Fatalf("os.Open() failed, err:%v", err.Error())
+		fatal(logger, "os.Open() failed", err)
 	}
 	defer f.Close()
 
 	feeModels, err := parking.GetFeeModels(f)
 	if err != nil {
-		log.Fatalf("parking.GetFeeModels() failed, err:%v", err.Error())
+		fatal(logger, "parking.GetFeeModels() failed", err)
+	}
+
+	// receipts carry on numbering from those of earlier runs in the ledger
+	ledger, err := internal.OpenLedger(ledgerFile)
+	if err != nil {
+		fatal(logger, "internal.OpenLedger() failed", err)
 	}
+	defer ledger.Close()
+
+	timeline, err := internal.OpenTimeline(timelineFile)
+	if err != nil {
+		fatal(logger, "internal.OpenTimeline() failed", err)
+	}
+	defer timeline.Close()
+
+	auditLog, err := audit.OpenLog(auditFile)
+	if err != nil {
+		fatal(logger, "audit.OpenLog() failed", err)
+	}
+	defer auditLog.Close()
 
 	feeModel := feeModels[parking.ModelType_Mall]
-	parkingLot := parkingFactory.New(parking.ModelType_Mall, feeModel.Fee, map[parking.VehicleType]internal.Inventory{
+	// every action is audited, within the decorators picked by the fee model
+	decorators, err := parkingFactory.Builtins{Logger: logger, Audit: auditLog}.WithDecorators(append(feeModel.Decorators, parkingFactory.DecoratorAudit)...)
+	if err != nil {
+		fatal(logger, "parkingFactory.Builtins.WithDecorators() failed", err)
+	}
+	parkingLot, err := parkingFactory.New("mall-1", parking.ModelType_Mall, feeModel.Fee, map[parking.VehicleType]internal.Inventory{
 		parking.VehicleType_Motorcycle: {
 			Total: 2,
 		},
-	})
+	}, internal.WithLedger(ledger), internal.WithTimeline(timeline), decorators, internal.WithLogger(logger))
+	if err != nil {
+		fatal(logger, "parkingFactory.New() failed", err)
+	}
 
 	// park motorcycle
 	result := parkingLot.Do(parking.Action{
 		ActionType:  parking.ActionType_Park,
 		VehicleType: parking.VehicleType_Motorcycle,
 	})
-	if result.ParkingReceipt != nil {
-		log.Printf("ParkingReceipt : %+v", *result.ParkingReceipt)
-	}
-	if result.ParkingTicket != nil {
-		log.Printf("ParkingTicket : %+v", *result.ParkingTicket)
-	}
-	if result.Err != nil {
-		log.Printf("Err : %+v", result.Err)
-	}
+	logResult(logger, result)
 
 	// un park motorcycle
 	result = parkingLot.Do(parking.Action{
@@ -48,13 +115,18 @@ func main() {
 		VehicleType: parking.VehicleType_Motorcycle,
 		TicketNumer: &result.ParkingTicket.TicketNumber,
 	})
-	if result.ParkingReceipt != nil {
-		log.Printf("ParkingReceipt : %+v", *result.ParkingReceipt)
-	}
+	logResult(logger, result)
+}
+
+// logResult logs the documents an Action returned
+func logResult(logger *slog.Logger, result parking.Result) {
 	if result.ParkingTicket != nil {
-		log.Printf("ParkingTicket : %+v", *result.ParkingTicket)
+		logger.Info("parking ticket", "ticket", *result.ParkingTicket)
+	}
+	if result.ParkingReceipt != nil {
+		logger.Info("parking receipt", "receipt", *result.ParkingReceipt)
 	}
 	if result.Err != nil {
-		log.Printf("Err : %+v", result.Err)
+		logger.Error("action failed", "code", parking.CodeOf(result.Err).String(), "err", result.Err.Error())
 	}
 }
@@ -1,6 +1,10 @@
 package parking
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type ModelType uint
 
@@ -75,16 +79,24 @@ type ActionType uint
 const (
 	ActionType_Park ActionType = iota + 1
 	ActionType_UnPark
+	ActionType_Reserve
+	ActionType_CancelReservation
+	ActionType_ModifyReservation
+	ActionType_Refund
 )
 
 func (s ActionType) String() string {
-	return [...]string{"", "Park", "UnPark"}[s]
+	return [...]string{"", "Park", "UnPark", "Reserve", "CancelReservation", "ModifyReservation", "Refund"}[s]
 }
 
 func (s *ActionType) FromString(val string) ActionType {
 	return map[string]ActionType{
-		"Park":   ActionType_Park,
-		"UnPark": ActionType_UnPark,
+		"Park":              ActionType_Park,
+		"UnPark":            ActionType_UnPark,
+		"Reserve":           ActionType_Reserve,
+		"CancelReservation": ActionType_CancelReservation,
+		"ModifyReservation": ActionType_ModifyReservation,
+		"Refund":            ActionType_Refund,
 	}[val]
 }
 
@@ -133,3 +145,337 @@ func (s *ChargeType) UnmarshalJSON(b []byte) error {
 	*s = s.FromString(v)
 	return nil
 }
+
+type ReservationStatus uint
+
+const (
+	ReservationStatus_Booked ReservationStatus = iota + 1
+	ReservationStatus_Cancelled
+	ReservationStatus_Arrived
+	ReservationStatus_NoShow
+)
+
+func (s ReservationStatus) String() string {
+	return [...]string{"", "Booked", "Cancelled", "Arrived", "NoShow"}[s]
+}
+
+func (s *ReservationStatus) FromString(val string) ReservationStatus {
+	return map[string]ReservationStatus{
+		"Booked":    ReservationStatus_Booked,
+		"Cancelled": ReservationStatus_Cancelled,
+		"Arrived":   ReservationStatus_Arrived,
+		"NoShow":    ReservationStatus_NoShow,
+	}[val]
+}
+
+func (s ReservationStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *ReservationStatus) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type PaymentTiming uint
+
+const (
+	PaymentTiming_OnExit PaymentTiming = iota + 1
+	PaymentTiming_OnEntry
+)
+
+func (s PaymentTiming) String() string {
+	return [...]string{"", "OnExit", "OnEntry"}[s]
+}
+
+func (s *PaymentTiming) FromString(val string) PaymentTiming {
+	return map[string]PaymentTiming{
+		"OnExit":  PaymentTiming_OnExit,
+		"OnEntry": PaymentTiming_OnEntry,
+	}[val]
+}
+
+func (s PaymentTiming) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *PaymentTiming) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type PaymentStatus uint
+
+const (
+	PaymentStatus_Pending PaymentStatus = iota + 1
+	PaymentStatus_Settled
+	PaymentStatus_Refunded
+)
+
+func (s PaymentStatus) String() string {
+	return [...]string{"", "Pending", "Settled", "Refunded"}[s]
+}
+
+func (s *PaymentStatus) FromString(val string) PaymentStatus {
+	return map[string]PaymentStatus{
+		"Pending":  PaymentStatus_Pending,
+		"Settled":  PaymentStatus_Settled,
+		"Refunded": PaymentStatus_Refunded,
+	}[val]
+}
+
+func (s PaymentStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *PaymentStatus) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type AdjustmentReason uint
+
+const (
+	AdjustmentReason_BarrierFailure AdjustmentReason = iota + 1
+	AdjustmentReason_Overcharge
+	AdjustmentReason_Goodwill
+	AdjustmentReason_Other
+	AdjustmentReason_Cancellation
+)
+
+func (s AdjustmentReason) String() string {
+	return [...]string{"", "BarrierFailure", "Overcharge", "Goodwill", "Other", "Cancellation"}[s]
+}
+
+func (s *AdjustmentReason) FromString(val string) AdjustmentReason {
+	return map[string]AdjustmentReason{
+		"BarrierFailure": AdjustmentReason_BarrierFailure,
+		"Overcharge":     AdjustmentReason_Overcharge,
+		"Goodwill":       AdjustmentReason_Goodwill,
+		"Other":          AdjustmentReason_Other,
+		"Cancellation":   AdjustmentReason_Cancellation,
+	}[val]
+}
+
+func (s AdjustmentReason) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *AdjustmentReason) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type DiscountType uint
+
+const (
+	DiscountType_Percentage DiscountType = iota + 1
+	DiscountType_Fixed
+	DiscountType_FreeMinutes
+	DiscountType_Cap
+)
+
+func (s DiscountType) String() string {
+	return [...]string{"", "Percentage", "Fixed", "FreeMinutes", "Cap"}[s]
+}
+
+func (s *DiscountType) FromString(val string) DiscountType {
+	return map[string]DiscountType{
+		"Percentage":  DiscountType_Percentage,
+		"Fixed":       DiscountType_Fixed,
+		"FreeMinutes": DiscountType_FreeMinutes,
+		"Cap":         DiscountType_Cap,
+	}[val]
+}
+
+func (s DiscountType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *DiscountType) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type Weekday time.Weekday
+
+func (s Weekday) String() string {
+	return time.Weekday(s).String()
+}
+
+// FromString parses the English name of the day, as time.Weekday names it
+func (s *Weekday) FromString(val string) (Weekday, error) {
+	day, ok := map[string]Weekday{
+		"Sunday":    Weekday(time.Sunday),
+		"Monday":    Weekday(time.Monday),
+		"Tuesday":   Weekday(time.Tuesday),
+		"Wednesday": Weekday(time.Wednesday),
+		"Thursday":  Weekday(time.Thursday),
+		"Friday":    Weekday(time.Friday),
+		"Saturday":  Weekday(time.Saturday),
+	}[val]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidCalendar, val)
+	}
+	return day, nil
+}
+
+func (s Weekday) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Weekday) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	day, err := s.FromString(v)
+	if err != nil {
+		return err
+	}
+	*s = day
+	return nil
+}
+
+type ErrorCode uint
+
+const (
+	ErrorCode_Unknown ErrorCode = iota
+	ErrorCode_NoSpace
+	ErrorCode_InvalidAction
+	ErrorCode_InvalidTicket
+	ErrorCode_TicketNotFound
+	ErrorCode_TicketExited
+	ErrorCode_ExitTime
+	ErrorCode_ChargeNotSupported
+	ErrorCode_VehicleNotAllowed
+	ErrorCode_VehicleMismatch
+	ErrorCode_ModelNotSupported
+	ErrorCode_ReservationNotFound
+	ErrorCode_ReservationWindow
+	ErrorCode_ReservationClosed
+	ErrorCode_PassNotFound
+	ErrorCode_PassExpired
+	ErrorCode_PassOverused
+	ErrorCode_PassNotAllowed
+	ErrorCode_DiscountNotFound
+	ErrorCode_EventWindow
+	ErrorCode_PaymentFailed
+	ErrorCode_ReceiptNotFound
+	ErrorCode_InvalidAdjustment
+	ErrorCode_InvalidInventory
+	ErrorCode_InvalidCalendar
+)
+
+func (s ErrorCode) String() string {
+	return [...]string{"Unknown", "NoSpace", "InvalidAction", "InvalidTicket", "TicketNotFound", "TicketExited", "ExitTime", "ChargeNotSupported", "VehicleNotAllowed", "VehicleMismatch", "ModelNotSupported", "ReservationNotFound", "ReservationWindow", "ReservationClosed", "PassNotFound", "PassExpired", "PassOverused", "PassNotAllowed", "DiscountNotFound", "EventWindow", "PaymentFailed", "ReceiptNotFound", "InvalidAdjustment", "InvalidInventory", "InvalidCalendar"}[s]
+}
+
+func (s *ErrorCode) FromString(val string) ErrorCode {
+	return map[string]ErrorCode{
+		"NoSpace":             ErrorCode_NoSpace,
+		"InvalidAction":       ErrorCode_InvalidAction,
+		"InvalidTicket":       ErrorCode_InvalidTicket,
+		"TicketNotFound":      ErrorCode_TicketNotFound,
+		"TicketExited":        ErrorCode_TicketExited,
+		"ExitTime":            ErrorCode_ExitTime,
+		"ChargeNotSupported":  ErrorCode_ChargeNotSupported,
+		"VehicleNotAllowed":   ErrorCode_VehicleNotAllowed,
+		"VehicleMismatch":     ErrorCode_VehicleMismatch,
+		"ModelNotSupported":   ErrorCode_ModelNotSupported,
+		"ReservationNotFound": ErrorCode_ReservationNotFound,
+		"ReservationWindow":   ErrorCode_ReservationWindow,
+		"ReservationClosed":   ErrorCode_ReservationClosed,
+		"PassNotFound":        ErrorCode_PassNotFound,
+		"PassExpired":         ErrorCode_PassExpired,
+		"PassOverused":        ErrorCode_PassOverused,
+		"PassNotAllowed":      ErrorCode_PassNotAllowed,
+		"DiscountNotFound":    ErrorCode_DiscountNotFound,
+		"EventWindow":         ErrorCode_EventWindow,
+		"PaymentFailed":       ErrorCode_PaymentFailed,
+		"ReceiptNotFound":     ErrorCode_ReceiptNotFound,
+		"InvalidAdjustment":   ErrorCode_InvalidAdjustment,
+		"InvalidInventory":    ErrorCode_InvalidInventory,
+		"InvalidCalendar":     ErrorCode_InvalidCalendar,
+	}[val]
+}
+
+func (s ErrorCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *ErrorCode) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
+
+type DomainEventType uint
+
+const (
+	DomainEventType_VehicleParked DomainEventType = iota + 1
+	DomainEventType_VehicleExited
+	DomainEventType_ParkRejected
+	DomainEventType_TariffChanged
+	DomainEventType_LotFull
+	DomainEventType_VehicleOverstayed
+)
+
+func (s DomainEventType) String() string {
+	return [...]string{"", "VehicleParked", "VehicleExited", "ParkRejected", "TariffChanged", "LotFull", "VehicleOverstayed"}[s]
+}
+
+func (s *DomainEventType) FromString(val string) DomainEventType {
+	return map[string]DomainEventType{
+		"VehicleParked":     DomainEventType_VehicleParked,
+		"VehicleExited":     DomainEventType_VehicleExited,
+		"ParkRejected":      DomainEventType_ParkRejected,
+		"TariffChanged":     DomainEventType_TariffChanged,
+		"LotFull":           DomainEventType_LotFull,
+		"VehicleOverstayed": DomainEventType_VehicleOverstayed,
+	}[val]
+}
+
+func (s DomainEventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *DomainEventType) UnmarshalJSON(b []byte) error {
+	var v string
+	err := json.Unmarshal(b, &v)
+	if err != nil {
+		return err
+	}
+	*s = s.FromString(v)
+	return nil
+}
@@ -0,0 +1,49 @@
+package parking
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeekday_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Weekday
+		wantErr error
+	}{
+		{
+			name: "Saturday is parsed",
+			json: `"Saturday"`,
+			want: Weekday(time.Saturday),
+		},
+		{
+			name: "Sunday is parsed",
+			json: `"Sunday"`,
+			want: Weekday(time.Sunday),
+		},
+		{
+			name:    "lowercase day must fail, not become Sunday",
+			json:    `"saturday"`,
+			wantErr: ErrInvalidCalendar,
+		},
+		{
+			name:    "unknown day must fail, not become Sunday",
+			json:    `"Caturday"`,
+			wantErr: ErrInvalidCalendar,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Weekday
+			err := json.Unmarshal([]byte(tt.json), &got)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got, "Weekday must match")
+			}
+		})
+	}
+}
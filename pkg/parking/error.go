@@ -1,13 +1,149 @@
 package parking
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
-	ErrNoSpace            = errors.New(" No space available")
-	ErrInvalidAction      = errors.New(" Invalid action")
-	ErrInvalidTicket      = errors.New(" Invalid ticket")
-	ErrExitTime           = errors.New(" Invalid exit time")
-	ErrChargeNotSupported = errors.New(" Invalid charge type not supported")
-	ErrVehicleNotAllowed  = errors.New(" The vehicle is not allowed to be parked")
-	ErrVehicleMismatch    = errors.New(" The vehicle on ticket is not the vehicle which was parked")
+	ErrNoSpace             = errors.New("no space available")
+	ErrInvalidAction       = errors.New("invalid action")
+	ErrInvalidTicket       = errors.New("invalid ticket")
+	ErrExitTime            = errors.New("invalid exit time")
+	ErrChargeNotSupported  = errors.New("invalid charge type not supported")
+	ErrVehicleNotAllowed   = errors.New("the vehicle is not allowed to be parked")
+	ErrVehicleMismatch     = errors.New("the vehicle on ticket is not the vehicle which was parked")
+	ErrModelNotSupported   = errors.New("parking lot model not supported")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationWindow   = errors.New("invalid reservation window")
+	ErrReservationClosed   = errors.New("reservation is no longer open")
+	ErrPassNotFound        = errors.New("pass not found")
+	ErrPassExpired         = errors.New("pass is not valid at this time")
+	ErrPassOverused        = errors.New("pass has no uses left")
+	ErrPassNotAllowed      = errors.New("pass is not valid at this parking lot")
+	ErrDiscountNotFound    = errors.New("discount code not found")
+	ErrEventWindow         = errors.New("invalid event window")
+	ErrPaymentFailed       = errors.New("payment could not be collected")
+	ErrReceiptNotFound     = errors.New("receipt not found")
+	ErrInvalidAdjustment   = errors.New("invalid adjustment")
+	ErrInvalidInventory    = errors.New("invalid inventory")
+	ErrInvalidCalendar     = errors.New("invalid rate calendar")
 )
+
+// Error carries the context of a failed Action on a Parking Lot
+type Error struct {
+	Code         ErrorCode
+	LotID        string
+	TicketNumber string
+	Details      string
+	Err          error // one of the Err* sentinels, or a typed error wrapping one
+}
+
+// NewError wraps err with the context of the Parking Lot it occurred on
+func NewError(err error, lotID, ticketNo, details string) *Error {
+	return &Error{
+		Code:         CodeOf(err),
+		LotID:        lotID,
+		TicketNumber: ticketNo,
+		Details:      details,
+		Err:          err,
+	}
+}
+
+func (e *Error) Error() string {
+	var msg strings.Builder
+	msg.WriteString("[" + e.Code.String() + "]")
+	if e.LotID != "" {
+		msg.WriteString(" lot " + e.LotID)
+	}
+	if e.TicketNumber != "" {
+		msg.WriteString(" ticket " + e.TicketNumber)
+	}
+	msg.WriteString(": " + e.Err.Error())
+	if e.Details != "" {
+		msg.WriteString(" (" + e.Details + ")")
+	}
+	return msg.String()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// CodeOf returns the ErrorCode of err, ErrorCode_Unknown if err is not a parking error
+func CodeOf(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	var notFound *TicketNotFoundError
+	if errors.As(err, &notFound) {
+		return ErrorCode_TicketNotFound
+	}
+	var exited *TicketExitedError
+	if errors.As(err, &exited) {
+		return ErrorCode_TicketExited
+	}
+	for sentinel, code := range map[error]ErrorCode{
+		ErrNoSpace:             ErrorCode_NoSpace,
+		ErrInvalidAction:       ErrorCode_InvalidAction,
+		ErrInvalidTicket:       ErrorCode_InvalidTicket,
+		ErrExitTime:            ErrorCode_ExitTime,
+		ErrChargeNotSupported:  ErrorCode_ChargeNotSupported,
+		ErrVehicleNotAllowed:   ErrorCode_VehicleNotAllowed,
+		ErrVehicleMismatch:     ErrorCode_VehicleMismatch,
+		ErrModelNotSupported:   ErrorCode_ModelNotSupported,
+		ErrReservationNotFound: ErrorCode_ReservationNotFound,
+		ErrReservationWindow:   ErrorCode_ReservationWindow,
+		ErrReservationClosed:   ErrorCode_ReservationClosed,
+		ErrPassNotFound:        ErrorCode_PassNotFound,
+		ErrPassExpired:         ErrorCode_PassExpired,
+		ErrPassOverused:        ErrorCode_PassOverused,
+		ErrPassNotAllowed:      ErrorCode_PassNotAllowed,
+		ErrDiscountNotFound:    ErrorCode_DiscountNotFound,
+		ErrEventWindow:         ErrorCode_EventWindow,
+		ErrPaymentFailed:       ErrorCode_PaymentFailed,
+		ErrReceiptNotFound:     ErrorCode_ReceiptNotFound,
+		ErrInvalidAdjustment:   ErrorCode_InvalidAdjustment,
+		ErrInvalidInventory:    ErrorCode_InvalidInventory,
+		ErrInvalidCalendar:     ErrorCode_InvalidCalendar,
+	} {
+		if errors.Is(err, sentinel) {
+			return code
+		}
+	}
+	return ErrorCode_Unknown
+}
+
+// TicketNotFoundError is returned when no vehicle was ever parked against the ticket
+type TicketNotFoundError struct {
+	TicketNumber string
+}
+
+func (e *TicketNotFoundError) Error() string {
+	return ErrInvalidTicket.Error() + ", ticket " + e.TicketNumber + " not found"
+}
+
+func (e *TicketNotFoundError) Unwrap() error { return ErrInvalidTicket }
+
+// TicketExitedError is returned when the vehicle parked against the ticket has already exited
+type TicketExitedError struct {
+	TicketNumber string
+}
+
+func (e *TicketExitedError) Error() string {
+	return ErrInvalidTicket.Error() + ", ticket " + e.TicketNumber + " already exited"
+}
+
+func (e *TicketExitedError) Unwrap() error { return ErrInvalidTicket }
+
+// VehicleMismatchError is returned when the vehicle presented is not the one parked against the ticket
+type VehicleMismatchError struct {
+	TicketNumber string
+	Parked       VehicleType
+	Presented    VehicleType
+}
+
+func (e *VehicleMismatchError) Error() string {
+	return ErrVehicleMismatch.Error() + ", ticket " + e.TicketNumber + " was issued to " + e.Parked.String() + " not " + e.Presented.String()
+}
+
+func (e *VehicleMismatchError) Unwrap() error { return ErrVehicleMismatch }
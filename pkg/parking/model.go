@@ -0,0 +1,18 @@
+package parking
+
+// AllowedVehicles lists the kinds of vehicle a Parking Lot of each model can park
+var AllowedVehicles = map[ModelType][]VehicleType{
+	ModelType_Mall:    {VehicleType_Motorcycle, VehicleType_CarSuv, VehicleType_BusTruck},
+	ModelType_Stadium: {VehicleType_Motorcycle, VehicleType_CarSuv},
+	ModelType_Airport: {VehicleType_Motorcycle, VehicleType_CarSuv},
+}
+
+// Allows tells if the vehicle can be parked at a Parking Lot of this model
+func (f ModelType) Allows(vehicleType VehicleType) bool {
+	for _, allowed := range AllowedVehicles[f] {
+		if allowed == vehicleType {
+			return true
+		}
+	}
+	return false
+}
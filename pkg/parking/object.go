@@ -6,30 +6,190 @@ import (
 
 // Action encapsulates a basic opration on a Parking Lot
 type Action struct {
-	ActionType  ActionType
-	VehicleType VehicleType
-	TicketNumer *string
+	ActionType        ActionType       `json:"actionType"`
+	VehicleType       VehicleType      `json:"vehicleType,omitempty"`
+	Plate             string           `json:"plate,omitempty"` // number plate of the vehicle parking
+	TicketNumer       *string          `json:"ticketNumber,omitempty"`
+	ReservationNumber *string          `json:"reservationNumber,omitempty"` // reservation being parked against, cancelled or modified
+	From              time.Time        `json:"from,omitempty"`              // time window of a reservation
+	Till              time.Time        `json:"till,omitempty"`
+	Prepay            bool             `json:"prepay,omitempty"`        // pay for the reservation window in advance
+	PassID            *string          `json:"passId,omitempty"`        // pass the vehicle parks with
+	DiscountCodes     []string         `json:"discountCodes,omitempty"` // validations & promo codes presented on exit
+	PaymentSource     string           `json:"paymentSource,omitempty"` // card or other source the fees are paid from
+	ReceiptNumber     *string          `json:"receiptNumber,omitempty"` // receipt being refunded
+	Amount            uint             `json:"amount,omitempty"`        // refunded, all of what is left if 0
+	Reason            AdjustmentReason `json:"reason,omitempty"`        // why the refund is issued
+	OperatorID        string           `json:"operatorId,omitempty"`    // operator issuing the refund
 }
 
 // Result encapsulates result of an Action on a Parking Lot
 type Result struct {
 	ParkingTicket  *Ticket
 	ParkingReceipt *Receipt
+	Reservation    *Reservation
+	Adjustment     *Adjustment
 	Err            error
 }
 
 // Ticket represents a Parking ticket
 type Ticket struct {
-	TicketNumber  string
-	SpotNumber    uint
-	EntryDateTime time.Time
+	TicketNumber      string
+	VehicleType       VehicleType
+	Plate             string
+	SpotNumber        uint
+	EntryDateTime     time.Time
+	ReservationNumber string  // set when the vehicle arrived against a reservation
+	PassID            string  // set when the vehicle parked with a pass
+	Event             string  // set when the vehicle entered during an event
+	Fees              uint    // paid on entry, flat fee of the event or of the first rate interval
+	Surge             float64 // multiplier of the fees locked at entry, none if 0
 }
 
 // Receipt represents a receipt a User recieves after surrendring the Parking Ticket
 type Receipt struct {
 	ReceiptNumber               string
-	EntryDateTime, ExitDateTime time.Time
+	ReservationNumber           string // set on the receipt of a prepaid reservation
+	VehicleType                 VehicleType
+	Band                        string    // rate interval the stay fell in, none for receipts issued on entry
+	EntryDateTime, ExitDateTime time.Time // of the stay, the reservation window on the receipt of a prepaid reservation
+	IssuedAt                    time.Time // when the receipt was settled, revenue is dated by it
 	Fees                        uint
+	Prepaid                     uint    // paid in advance with the reservation or on entry, not included in Fees
+	PassID                      string  // set when the stay was covered by a pass
+	Surge                       float64 // multiplier of the fees locked at entry, none if 0
+	PaymentStatus               PaymentStatus
+	PaymentID                   string // set once settled
+	Refunded                    uint   // credited back by adjustments
+	LineItems                   []LineItem
+}
+
+// Adjustment is a credit issued against a settled Receipt
+type Adjustment struct {
+	AdjustmentNumber string
+	ReceiptNumber    string
+	Amount           uint
+	Reason           AdjustmentReason
+	OperatorID       string
+	DateTime         time.Time
+}
+
+// LineItem is a single charge on a Receipt, discounts are negative
+type LineItem struct {
+	Description string
+	Amount      int
+}
+
+// TicketQuery filters the active tickets of a Parking Lot, zero fields match any ticket
+type TicketQuery struct {
+	VehicleType              VehicleType
+	EnteredFrom, EnteredTill time.Time // entry time window
+	Plate                    string    // part of the number plate, case insensitive
+	SpotNumber               uint
+	Level                    uint // counted from 1
+	Offset, Limit            int  // page of the matching tickets, all of them if Limit is 0 or less
+}
+
+// TicketPage is a page of the active tickets matching a TicketQuery, in order of entry
+type TicketPage struct {
+	Tickets []Ticket
+	Total   int // tickets matching the query
+}
+
+// Reservation represents a spot booked for a vehicle over a time window
+type Reservation struct {
+	ReservationNumber string
+	VehicleType       VehicleType
+	From, Till        time.Time
+	Status            ReservationStatus
+	Prepaid           uint   // paid in advance for the window
+	ReceiptNumber     string // of the prepaid amount, refunded on cancellation
+	PaymentID         string // of the prepaid amount
+}
+
+// Pass lets a vehicle park without paying per stay, over its validity window
+type Pass struct {
+	PassID               string
+	VehicleType          VehicleType
+	ValidFrom, ValidTill time.Time
+	Lots                 []string // IDs of Parking Lots the pass is valid at, all if empty
+	MaxUses              uint     // parks allowed, unlimited if 0
+	Used                 uint
+}
+
+// ValidAt tells if the pass can be used at the Parking Lot
+func (p Pass) ValidAt(lotID string) bool {
+	if len(p.Lots) == 0 {
+		return true
+	}
+	for _, id := range p.Lots {
+		if id == lotID {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a time window over which vehicles entering a Stadium pay a flat fee on entry
+type Event struct {
+	Name       string
+	Start, End time.Time
+	Fees       map[VehicleType]uint // flat fee per vehicle type, vehicles not listed pay the regular fees
+}
+
+// Occupancy is a snapshot of the spots of a Parking Lot
+type Occupancy struct {
+	LotID    string             `json:"lotId"`
+	At       time.Time          `json:"at"`
+	Vehicles []VehicleOccupancy `json:"vehicles"` // in order of vehicle type
+}
+
+// VehicleOccupancy counts the spots of a vehicle type, reserved spots are held for reservations
+// whose vehicle has not arrived yet
+type VehicleOccupancy struct {
+	VehicleType VehicleType `json:"vehicleType"`
+	Spots
+	Levels []Spots `json:"levels,omitempty"` // per level in order, reserved spots are not on a level
+}
+
+// OccupancyChange is the occupancy of a vehicle type once a vehicle parked or exited
+type OccupancyChange struct {
+	LotID       string      `json:"lotId"`
+	At          time.Time   `json:"at"`
+	VehicleType VehicleType `json:"vehicleType"`
+	Occupied    uint        `json:"occupied"`
+	Total       uint        `json:"total"`
+}
+
+// DomainEvent tells other systems about the activity of a Parking Lot,
+// fields not relevant to its Type are left empty
+type DomainEvent struct {
+	ID          string          `json:"id"` // unique, for subscribers to drop events delivered more than once
+	Type        DomainEventType `json:"type"`
+	LotID       string          `json:"lotId"`
+	At          time.Time       `json:"at"`
+	VehicleType VehicleType     `json:"vehicleType,omitempty"`
+	Ticket      *Ticket         `json:"ticket,omitempty"`  // issued to the vehicle parked or overstaying
+	Receipt     *Receipt        `json:"receipt,omitempty"` // settled by the vehicle exited, or paid on entry
+	Reason      ErrorCode       `json:"reason,omitempty"`  // the park was rejected for
+	Details     string          `json:"details,omitempty"`
+	Fee         *Fee            `json:"fee,omitempty"` // tariff in effect from now on
+}
+
+// Spots counts the spots of a vehicle type
+type Spots struct {
+	Total    uint `json:"total"`
+	Occupied uint `json:"occupied"`
+	Reserved uint `json:"reserved"`
+	Free     uint `json:"free"`
+}
+
+// Discount is a rule reducing the fees of a stay, presented by its code on exit
+type Discount struct {
+	Code      string       `json:"code"`
+	Type      DiscountType `json:"type"`
+	Value     uint         `json:"value"`     // percent, amount, minutes or the maximum fees, as per Type
+	Stackable bool         `json:"stackable"` // can be combined with other discounts
 }
 
 // FeeModels encapsulates all FeeModel on which a Parking Lot works
@@ -37,24 +197,55 @@ type FeeModels map[ModelType]FeeModel
 
 // FeeModel represents a basic unit of Parking Lot
 type FeeModel struct {
-	Model ModelType `json:"model"`
-	Fee   Fee       `json:"Fee"`
+	Model      ModelType `json:"model"`
+	Fee        Fee       `json:"Fee"`
+	Decorators []string  `json:"decorators,omitempty"` // names of the built-in decorators wrapping its Parking Lots, outermost first
 }
 
 type Fee struct {
-	Charge   ChargeType `json:"charge"`
-	Vehicles []Vehicle  `json:"vehicles"`
+	Charge          ChargeType `json:"charge"`
+	Vehicles        []Vehicle  `json:"vehicles"`
+	GraceMinutes    uint       `json:"graceMinutes,omitempty"`    // stays up to this long are free
+	RoundingMinutes uint       `json:"roundingMinutes,omitempty"` // stays are rounded up to a multiple of it, model default if 0
+	MinimumCharge   uint       `json:"minimumCharge,omitempty"`   // least a stay past the grace period pays
+	DailyCap        uint       `json:"dailyCap,omitempty"`        // most every 24 hours of a PerHour stay pays, no cap if 0
+	Holidays        []string   `json:"holidays,omitempty"`        // dates as 2006-01-02, for rates applicable on holidays
 }
 
 type Vehicle struct {
-	Kind  VehicleType `json:"kind"`
-	Rates []Rate      `json:"rates"`
+	Kind    VehicleType   `json:"kind"`
+	Rates   []Rate        `json:"rates"`
+	Prepaid []PrepaidRate `json:"prepaid,omitempty"`
+	Surge   []Surge       `json:"surge,omitempty"` // honoured by Airport
+}
+
+// Surge multiplies the fees of vehicles entering once the occupancy of their type reaches the threshold
+type Surge struct {
+	Occupancy  float64 `json:"occupancy"` // ratio of spots occupied or held, 0.9 for 90% full
+	Multiplier float64 `json:"multiplier"`
 }
 
 type Rate struct {
-	From uint `json:"from"`
-	Till uint `json:"till"`
-	Rate uint `json:"rate"`
+	From uint      `json:"from"`
+	Till uint      `json:"till"`
+	Rate uint      `json:"rate"`
+	When *Calendar `json:"when,omitempty"` // base rate if nil, applies only when the calendar matches otherwise
+}
+
+// Calendar restricts a Rate to some days and time of day. The rate applies on the Weekdays or on Holidays
+// of the Fee, any day if neither is set, between StartTime and EndTime ("22:00", "06:00"), all day if not set
+type Calendar struct {
+	Weekdays  []Weekday `json:"weekdays,omitempty"`
+	Holidays  bool      `json:"holidays,omitempty"`
+	StartTime string    `json:"startTime,omitempty"`
+	EndTime   string    `json:"endTime,omitempty"`
+}
+
+// PrepaidRate is the per day rate of a reservation paid in advance,
+// applicable when booked at least DaysAhead days before the window starts
+type PrepaidRate struct {
+	DaysAhead uint `json:"daysAhead"`
+	Rate      uint `json:"rate"`
 }
 
 // Rates can be jumbled, we need to traverse from lowest to highest time
@@ -2,6 +2,43 @@ package parking
 
 // ParkingLot represents the contract needed for a parking lot
 type ParkingLot interface {
+	GetID() string
 	GetType() ModelType
 	Do(action Action) Result
+	Occupancy() Occupancy
+	OccupancyChanges() []OccupancyChange
+	Ticket(ticketNo string) (Ticket, error)
+	Tickets(query TicketQuery) TicketPage
+	SetFee(fee Fee)
+	CheckOverstays()
+}
+
+// Subscriber reacts to the domain events of Parking Lots
+type Subscriber interface {
+	Notify(event DomainEvent) error
+}
+
+// Decorator wraps a Parking Lot to add a cross-cutting concern around its methods,
+// such as logging, timing or auditing every Action
+type Decorator func(ParkingLot) ParkingLot
+
+// Unwrap returns the Parking Lot at the core of the decorators wrapping lot, of its concrete type, to call
+// methods specific to its model such as Schedule of Stadium. Decorators are unwrapped by their Unwrap method
+func Unwrap(lot ParkingLot) ParkingLot {
+	for {
+		decorated, ok := lot.(interface{ Unwrap() ParkingLot })
+		if !ok {
+			return lot
+		}
+		lot = decorated.Unwrap()
+	}
+}
+
+// Chain wraps the Parking Lot in the decorators, the first one being the outermost:
+// it sees every call first and its result last
+func Chain(lot ParkingLot, decorators ...Decorator) ParkingLot {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		lot = decorators[i](lot)
+	}
+	return lot
 }
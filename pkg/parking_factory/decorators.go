@@ -0,0 +1,58 @@
+package parking_factory
+
+import (
+	"fmt"
+	"log/slog"
+	"sahaj/internal"
+	"sahaj/internal/audit"
+	"sahaj/internal/decorator"
+	"sahaj/internal/metrics"
+	"sahaj/pkg/parking"
+)
+
+// Names of the built-in decorators, to pick them from config
+const (
+	DecoratorLogging = "logging"
+	DecoratorTiming  = "timing"
+	DecoratorAudit   = "audit"
+	DecoratorMetrics = "metrics"
+)
+
+// Builtins holds what the built-in decorators record to. A decorator can only be picked by name
+// once what it records to is set, but logging which logs to the default logger if nil
+type Builtins struct {
+	Logger  *slog.Logger
+	Timings *decorator.Timings
+	Audit   *audit.Log
+	Metrics *metrics.Metrics
+}
+
+// WithDecorators wraps the Parking Lot created by New in the built-in decorators named, outermost first,
+// such as the decorators of its fee model. Picking metrics also times the fee calculations of the Parking Lot
+func (b Builtins) WithDecorators(names ...string) (internal.Option, error) {
+	var decorators []parking.Decorator
+	var feeTimer internal.FeeTimer
+	for _, name := range names {
+		switch {
+		case name == DecoratorLogging:
+			decorators = append(decorators, decorator.Logging(b.Logger))
+		case name == DecoratorTiming && b.Timings != nil:
+			decorators = append(decorators, decorator.Timing(b.Timings))
+		case name == DecoratorAudit && b.Audit != nil:
+			decorators = append(decorators, audit.Decorator(b.Audit))
+		case name == DecoratorMetrics && b.Metrics != nil:
+			decorators = append(decorators, metrics.Decorator(b.Metrics))
+			feeTimer = metrics.FeeTimer(b.Metrics)
+		case name == DecoratorTiming || name == DecoratorAudit || name == DecoratorMetrics:
+			return nil, fmt.Errorf("decorator %q has nothing to record to", name)
+		default:
+			return nil, fmt.Errorf("unknown decorator %q, must be one of %s, %s, %s or %s", name, DecoratorLogging, DecoratorTiming, DecoratorAudit, DecoratorMetrics)
+		}
+	}
+	return func(p *internal.Parking) {
+		internal.WithDecorators(decorators...)(p)
+		if feeTimer != nil {
+			internal.WithFeeTimer(feeTimer)(p)
+		}
+	}, nil
+}
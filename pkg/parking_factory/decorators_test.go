@@ -0,0 +1,75 @@
+package parking_factory
+
+import (
+	"bytes"
+	"log/slog"
+	"sahaj/internal"
+	"sahaj/internal/decorator"
+	"sahaj/internal/metrics"
+	"sahaj/internal/stadium"
+	"sahaj/pkg/parking"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltins_WithDecorators(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		wantErr bool
+	}{
+		{
+			name:  "built-in decorators should be picked by name",
+			names: []string{DecoratorLogging, DecoratorTiming, DecoratorMetrics},
+		},
+		{
+			name:    "unknown decorator should fail",
+			names:   []string{"tracing"},
+			wantErr: true,
+		},
+		{
+			name:    "decorator with nothing to record to should fail",
+			names:   []string{DecoratorAudit},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			builtins := Builtins{
+				Logger:  internal.NewLogger(&buf, slog.LevelInfo, false),
+				Timings: &decorator.Timings{},
+				Metrics: metrics.New(),
+			}
+			opt, err := builtins.WithDecorators(tt.names...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithDecorators() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			lot, err := New("stadium-1", parking.ModelType_Stadium, parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+				parking.VehicleType_Motorcycle: {
+					Total: 1,
+				},
+			}, opt)
+			assert.NoError(t, err, "New must not fail")
+
+			lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+			assert.Contains(t, buf.String(), "action done", "action must be logged")
+			assert.Len(t, builtins.Timings.Timings(), 1, "action must be timed")
+			var text bytes.Buffer
+			assert.NoError(t, builtins.Metrics.WriteText(&text), "WriteText must not fail")
+			assert.True(t, strings.Contains(text.String(), `parking_vehicles_parked_total{lot="stadium-1",vehicle_type="Motorcycle"} 1`), "park must be counted")
+
+			// methods of the model are reached by unwrapping the decorators
+			core, ok := parking.Unwrap(lot).(*stadium.ParkingLot)
+			assert.True(t, ok, "Parking Lot must unwrap to its model")
+			assert.NoError(t, core.Schedule(parking.Event{Name: "Final", Start: time.Now().Add(time.Hour), End: time.Now().Add(3 * time.Hour)}), "Schedule must not fail")
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package parking_factory
 
 import (
+	"errors"
 	"sahaj/internal"
 	"sahaj/internal/airport"
 	"sahaj/internal/mall"
@@ -8,15 +9,45 @@ import (
 	"sahaj/pkg/parking"
 )
 
-// New creates a new Parking Lot
-func New(modelType parking.ModelType, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory) parking.ParkingLot {
+// New creates a new Parking Lot, failing if the inventory has vehicles the model does not allow.
+// The Parking Lot is wrapped in the decorators set by internal.WithDecorators, if any
+func New(id string, modelType parking.ModelType, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory, opts ...internal.Option) (parking.ParkingLot, error) {
+	logger := internal.Logger(opts...).With(internal.LogLot, id)
+	lot, err := newLot(id, modelType, fee, inventory, opts...)
+	// the model is only named once newLot has validated it, an unknown one has no name
+	if !errors.Is(err, parking.ErrModelNotSupported) {
+		logger = logger.With("model", modelType.String())
+	}
+	if err != nil {
+		logger.Error("parking lot not created", "code", parking.CodeOf(err).String(), "err", err.Error())
+		return nil, err
+	}
+	decorators := internal.Decorators(opts...)
+	logger.Info("parking lot created", "vehicle_types", len(inventory), "decorators", len(decorators))
+	return parking.Chain(lot, decorators...), nil
+}
+
+func newLot(id string, modelType parking.ModelType, fee parking.Fee, inventory map[parking.VehicleType]internal.Inventory, opts ...internal.Option) (parking.ParkingLot, error) {
+	// lots are returned as concrete types, nil pointers must not end up in a non nil interface
 	switch modelType {
 	case parking.ModelType_Mall:
-		return mall.New(fee, inventory)
+		lot, err := mall.New(id, fee, inventory, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return lot, nil
 	case parking.ModelType_Airport:
-		return airport.New(fee, inventory)
+		lot, err := airport.New(id, fee, inventory, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return lot, nil
 	case parking.ModelType_Stadium:
-		return stadium.New(fee, inventory)
+		lot, err := stadium.New(id, fee, inventory, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return lot, nil
 	}
-	return nil
+	return nil, parking.NewError(parking.ErrModelNotSupported, id, "", "")
 }
@@ -1,6 +1,8 @@
 package parking_factory
 
 import (
+	"bytes"
+	"log/slog"
 	"reflect"
 	"sahaj/internal"
 	"sahaj/internal/airport"
@@ -14,14 +16,16 @@ import (
 
 func TestNew(t *testing.T) {
 	type args struct {
+		id        string
 		modelType parking.ModelType
 		fee       parking.Fee
 		inventory map[parking.VehicleType]internal.Inventory
 	}
 	tests := []struct {
-		name string
-		args args
-		want parking.ParkingLot
+		name    string
+		args    args
+		want    parking.ParkingLot
+		wantErr error
 	}{
 		{
 			name: "ModelType Mall should create Mall Parking Lot",
@@ -56,14 +60,94 @@ func TestNew(t *testing.T) {
 				fee:       parking.Fee{},
 				inventory: map[parking.VehicleType]internal.Inventory{},
 			},
-			want: nil,
+			want:    nil,
+			wantErr: parking.ErrModelNotSupported,
+		},
+		{
+			name: "out of range ModelType should not create any Parking Lot",
+			args: args{
+				modelType: parking.ModelType(7),
+				fee:       parking.Fee{},
+				inventory: map[parking.VehicleType]internal.Inventory{},
+			},
+			want:    nil,
+			wantErr: parking.ErrModelNotSupported,
+		},
+		{
+			name: "Bus/Truck in Airport inventory should not create any Parking Lot",
+			args: args{
+				modelType: parking.ModelType_Airport,
+				fee:       parking.Fee{},
+				inventory: map[parking.VehicleType]internal.Inventory{
+					parking.VehicleType_BusTruck: {
+						Total: 10,
+					},
+				},
+			},
+			want:    nil,
+			wantErr: parking.ErrVehicleNotAllowed,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := New(tt.args.modelType, tt.args.fee, tt.args.inventory); !reflect.DeepEqual(got, tt.want) {
+			got, err := New(tt.args.id, tt.args.modelType, tt.args.fee, tt.args.inventory)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.want == nil {
+				assert.Nil(t, got, "New() must not create Parking Lot")
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				assert.IsType(t, tt.want, got)
 			}
 		})
 	}
 }
+
+// recorder notes the calls to Do of the Parking Lot it wraps
+type recorder struct {
+	parking.ParkingLot
+	name  string
+	calls *[]string
+}
+
+func (r recorder) Do(action parking.Action) parking.Result {
+	*r.calls = append(*r.calls, r.name)
+	return r.ParkingLot.Do(action)
+}
+
+func TestNew_Decorators(t *testing.T) {
+	var calls []string
+	decorator := func(name string) parking.Decorator {
+		return func(lot parking.ParkingLot) parking.ParkingLot {
+			return recorder{ParkingLot: lot, name: name, calls: &calls}
+		}
+	}
+	lot, err := New("mall-1", parking.ModelType_Mall, parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_Motorcycle: {
+			Total: 1,
+		},
+	}, internal.WithDecorators(decorator("outer"), decorator("inner")))
+	assert.NoError(t, err, "New must not fail")
+
+	got := lot.Do(parking.Action{ActionType: parking.ActionType_Park, VehicleType: parking.VehicleType_Motorcycle})
+	assert.NoError(t, got.Err, "Err must be nil")
+	assert.Equal(t, []string{"outer", "inner"}, calls, "decorators must be called outermost first")
+	assert.Equal(t, "mall-1", lot.GetID(), "other methods must reach the Parking Lot")
+}
+
+func TestNew_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := internal.NewLogger(&buf, slog.LevelInfo, false)
+
+	_, err := New("mall-1", parking.ModelType_Mall, parking.Fee{}, map[parking.VehicleType]internal.Inventory{}, internal.WithLogger(logger))
+	assert.NoError(t, err, "New must not fail")
+	assert.Contains(t, buf.String(), `level=INFO msg="parking lot created" lot=mall-1 model=Mall`, "creation must be logged")
+
+	_, err = New("airport-1", parking.ModelType_Airport, parking.Fee{}, map[parking.VehicleType]internal.Inventory{
+		parking.VehicleType_BusTruck: {
+			Total: 10,
+		},
+	}, internal.WithLogger(logger))
+	assert.Error(t, err, "New must fail")
+	assert.Contains(t, buf.String(), `level=ERROR msg="parking lot not created" lot=airport-1 model=Airport code=VehicleNotAllowed`, "failure must be logged")
+}
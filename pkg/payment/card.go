@@ -0,0 +1,40 @@
+package payment
+
+import "fmt"
+
+// FakeCard is a card Gateway for local testing, it declines payments without a card
+// and from the cards it was told to decline
+type FakeCard struct {
+	book     *book
+	declined map[string]bool
+}
+
+// NewFakeCard creates a FakeCard Gateway declining the given cards
+func NewFakeCard(declined ...string) *FakeCard {
+	c := &FakeCard{book: newBook("CARD"), declined: map[string]bool{}}
+	for _, card := range declined {
+		c.declined[card] = true
+	}
+	return c
+}
+
+// Authorize holds the amount on the card
+func (c *FakeCard) Authorize(amount uint, source string) (string, error) {
+	if source == "" {
+		return "", fmt.Errorf("%w: no card", ErrDeclined)
+	}
+	if c.declined[source] {
+		return "", fmt.Errorf("%w: card %s", ErrDeclined, source)
+	}
+	return c.book.authorize(amount), nil
+}
+
+// Capture collects the authorized amount from the card
+func (c *FakeCard) Capture(authorizationID string) (string, error) {
+	return c.book.capture(authorizationID)
+}
+
+// Refund credits part or all of the payment back to the card
+func (c *FakeCard) Refund(paymentID string, amount uint) error {
+	return c.book.refund(paymentID, amount)
+}
@@ -0,0 +1,26 @@
+package payment
+
+// Cash is a Gateway for cash handed over at the barrier, it never declines
+type Cash struct {
+	book *book
+}
+
+// NewCash creates a Cash Gateway
+func NewCash() *Cash {
+	return &Cash{book: newBook("CASH")}
+}
+
+// Authorize holds the amount, the source is ignored
+func (c *Cash) Authorize(amount uint, source string) (string, error) {
+	return c.book.authorize(amount), nil
+}
+
+// Capture collects the authorized amount
+func (c *Cash) Capture(authorizationID string) (string, error) {
+	return c.book.capture(authorizationID)
+}
+
+// Refund hands back part or all of the payment
+func (c *Cash) Refund(paymentID string, amount uint) error {
+	return c.book.refund(paymentID, amount)
+}
@@ -0,0 +1,93 @@
+package payment
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDeclined              = errors.New("payment declined")
+	ErrAuthorizationNotFound = errors.New("authorization not found")
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrRefundExceeded        = errors.New("refund exceeds the amount captured")
+)
+
+// Gateway collects the payment of receipts
+type Gateway interface {
+	// Authorize holds the amount on the payment source, returning the authorization ID
+	Authorize(amount uint, source string) (string, error)
+	// Capture collects an authorized amount, returning the payment ID
+	Capture(authorizationID string) (string, error)
+	// Refund returns part or all of a captured payment
+	Refund(paymentID string, amount uint) error
+}
+
+type payment struct {
+	amount, refunded uint
+}
+
+// book keeps the authorizations & payments of a Gateway
+type book struct {
+	mu             sync.Mutex
+	prefix         string
+	seq            uint
+	authorizations map[string]uint
+	payments       map[string]*payment
+}
+
+// newBook creates the book of a Gateway, its IDs are told apart from those of any other
+// Gateway, even one of an earlier run, by a random token after the prefix
+func newBook(prefix string) *book {
+	token := make([]byte, 4)
+	if _, err := rand.Read(token); err != nil {
+		// without randomness, the time the Gateway was created tells it apart
+		binary.BigEndian.PutUint32(token, uint32(time.Now().UnixNano()))
+	}
+	return &book{
+		prefix:         prefix + "-" + hex.EncodeToString(token),
+		authorizations: map[string]uint{},
+		payments:       map[string]*payment{},
+	}
+}
+
+func (b *book) authorize(amount uint) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	id := fmt.Sprintf("%s-A-%04d", b.prefix, b.seq)
+	b.authorizations[id] = amount
+	return id
+}
+
+func (b *book) capture(authorizationID string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	amount, ok := b.authorizations[authorizationID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrAuthorizationNotFound, authorizationID)
+	}
+	delete(b.authorizations, authorizationID)
+	b.seq++
+	id := fmt.Sprintf("%s-P-%04d", b.prefix, b.seq)
+	b.payments[id] = &payment{amount: amount}
+	return id, nil
+}
+
+func (b *book) refund(paymentID string, amount uint) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPaymentNotFound, paymentID)
+	}
+	if p.refunded+amount > p.amount {
+		return fmt.Errorf("%w: %d of %d refunded", ErrRefundExceeded, p.refunded, p.amount)
+	}
+	p.refunded += amount
+	return nil
+}
@@ -0,0 +1,75 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateway(t *testing.T) {
+	tests := []struct {
+		name       string
+		gateway    Gateway
+		source     string
+		refund     uint
+		wantErr    error
+		wantRefund error
+	}{
+		{
+			name:    "cash is always collected",
+			gateway: NewCash(),
+			refund:  40,
+		},
+		{
+			name:    "card is charged",
+			gateway: NewFakeCard("4000"),
+			source:  "4242",
+			refund:  100,
+		},
+		{
+			name:    "declined card is not charged",
+			gateway: NewFakeCard("4000"),
+			source:  "4000",
+			wantErr: ErrDeclined,
+		},
+		{
+			name:    "payment without a card is declined",
+			gateway: NewFakeCard(),
+			wantErr: ErrDeclined,
+		},
+		{
+			name:       "refund can not exceed the payment",
+			gateway:    NewFakeCard(),
+			source:     "4242",
+			refund:     101,
+			wantRefund: ErrRefundExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizationID, err := tt.gateway.Authorize(100, tt.source)
+			assert.ErrorIs(t, err, tt.wantErr, "Err must match, want %v, got %v", tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			paymentID, err := tt.gateway.Capture(authorizationID)
+			assert.Nil(t, err, "Err must be nil")
+			_, err = tt.gateway.Capture(authorizationID)
+			assert.ErrorIs(t, err, ErrAuthorizationNotFound, "authorization must be captured once")
+			err = tt.gateway.Refund(paymentID, tt.refund)
+			assert.ErrorIs(t, err, tt.wantRefund, "Err must match, want %v, got %v", tt.wantRefund, err)
+		})
+	}
+}
+
+func TestGateway_UniqueIDs(t *testing.T) {
+	// a new Gateway, as after a restart, must not issue the IDs of an earlier one
+	before, restarted := NewCash(), NewCash()
+	authorizationID, _ := before.Authorize(100, "")
+	paymentID, _ := before.Capture(authorizationID)
+	authorizationID, _ = restarted.Authorize(10, "")
+	restartedID, _ := restarted.Capture(authorizationID)
+	assert.NotEqual(t, paymentID, restartedID, "payment IDs must be unique across gateways")
+	assert.ErrorIs(t, restarted.Refund(paymentID, 10), ErrPaymentNotFound, "payment of an earlier gateway must not be refunded")
+	assert.NoError(t, restarted.Refund(restartedID, 10), "payment must be refunded in full")
+}
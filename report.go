@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sahaj/internal"
+	"sahaj/internal/report"
+	"time"
+)
+
+// runReport exports the revenue report of a ledger file, or the occupancy report of a timeline file,
+// usage: sahaj report [-ledger ledger.jsonl] [-period day|week|month] [-format csv|json]
+// or: sahaj report -occupancy [-timeline occupancy.jsonl] [-interval 1h] [-format csv|json]
+func runReport(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	ledgerPath := fs.String("ledger", ledgerFile, "ledger file the receipts were recorded in")
+	period := fs.String("period", report.Day, "revenue by day, week or month")
+	occupancy := fs.Bool("occupancy", false, "report the occupancy of the timeline rather than the revenue of the ledger")
+	timelinePath := fs.String("timeline", timelineFile, "timeline file the occupancy changes were recorded in")
+	interval := fs.Duration("interval", time.Hour, "occupancy sampled every interval")
+	format := fs.String("format", "csv", "export as csv or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("invalid format %q, must be csv or json", *format)
+	}
+	if *occupancy {
+		return runOccupancyReport(*timelinePath, *interval, *format, w)
+	}
+
+	f, err := os.Open(*ledgerPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := internal.ReadLedger(f)
+	if err != nil {
+		return err
+	}
+	r, err := report.Build(entries, *period)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		return r.WriteJSON(w)
+	}
+	return r.WriteCSV(w)
+}
+
+// runOccupancyReport exports the occupancy report of a timeline file
+func runOccupancyReport(timelinePath string, interval time.Duration, format string, w io.Writer) error {
+	f, err := os.Open(timelinePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	changes, err := internal.ReadTimeline(f)
+	if err != nil {
+		return err
+	}
+	o, err := report.BuildOccupancy(changes, interval)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return o.WriteJSON(w)
+	}
+	return o.WriteCSV(w)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sahaj/internal"
+	"sahaj/internal/metrics"
+	"sahaj/pkg/parking"
+	parkingFactory "sahaj/pkg/parking_factory"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// runServe serves a Parking Lot per fee model, taking actions as JSON on /lots/<id>/actions
+// and exposing their metrics on /metrics for Prometheus,
+// usage: sahaj serve [-addr :8080] [-models sample.json] [-spots 10]
+func runServe(args []string, logger *slog.Logger) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	modelsPath := fs.String("models", "sample.json", "fee models of the Parking Lots")
+	spots := fs.Uint("spots", 10, "spots per vehicle type of every Parking Lot")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*modelsPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	feeModels, err := parking.GetFeeModels(f)
+	if err != nil {
+		return err
+	}
+
+	m := metrics.New()
+	s := &server{lots: map[string]parking.ParkingLot{}}
+	modelTypes := make([]parking.ModelType, 0, len(feeModels))
+	for modelType := range feeModels {
+		modelTypes = append(modelTypes, modelType)
+	}
+	sort.Slice(modelTypes, func(i, j int) bool { return modelTypes[i] < modelTypes[j] })
+	for _, modelType := range modelTypes {
+		fee := feeModels[modelType].Fee
+		decorators, err := parkingFactory.Builtins{Logger: logger, Metrics: m}.WithDecorators(append(feeModels[modelType].Decorators, parkingFactory.DecoratorMetrics)...)
+		if err != nil {
+			return err
+		}
+		inventory := map[parking.VehicleType]internal.Inventory{}
+		for _, vehicle := range fee.Vehicles {
+			if modelType.Allows(vehicle.Kind) {
+				inventory[vehicle.Kind] = internal.Inventory{Total: *spots}
+			}
+		}
+		id := strings.ToLower(modelType.String()) + "-1"
+		lot, err := parkingFactory.New(id, modelType, fee, inventory, decorators, internal.WithLogger(logger))
+		if err != nil {
+			return err
+		}
+		s.lots[id] = lot
+		logger.Info("serving parking lot", internal.LogLot, id, "path", "/lots/"+id+"/actions")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	mux.Handle("/lots/", s)
+	logger.Info("listening", "addr", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// server takes the actions on its Parking Lots one at a time, they are not safe for concurrent use
+type server struct {
+	mu   sync.Mutex
+	lots map[string]parking.ParkingLot
+}
+
+// response is the result of an action as JSON
+type response struct {
+	Ticket      *parking.Ticket      `json:"ticket,omitempty"`
+	Receipt     *parking.Receipt     `json:"receipt,omitempty"`
+	Reservation *parking.Reservation `json:"reservation,omitempty"`
+	Adjustment  *parking.Adjustment  `json:"adjustment,omitempty"`
+	ErrorCode   parking.ErrorCode    `json:"errorCode,omitempty"`
+	Error       string               `json:"error,omitempty"`
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/lots/"), "/actions")
+	lot, ok := s.lots[id]
+	if !ok || !strings.HasSuffix(r.URL.Path, "/actions") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var action parking.Action
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		http.Error(w, fmt.Sprintf("invalid action: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	res := lot.Do(action)
+	s.mu.Unlock()
+
+	resp := response{Ticket: res.ParkingTicket, Receipt: res.ParkingReceipt, Reservation: res.Reservation, Adjustment: res.Adjustment}
+	status := http.StatusOK
+	if res.Err != nil {
+		resp.ErrorCode = parking.CodeOf(res.Err)
+		resp.Error = res.Err.Error()
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
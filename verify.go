@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sahaj/internal/audit"
+)
+
+// runVerify checks the hash chain of an audit log file, printing its head to check it against later,
+// usage: sahaj verify [-audit audit.jsonl] [-head seq:hash]
+func runVerify(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	auditPath := fs.String("audit", auditFile, "audit log file the actions were recorded in")
+	headVal := fs.String("head", "", "seq:hash of the head printed by an earlier verify, the log must still reach")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var head audit.Head
+	if *headVal != "" {
+		parsed, err := audit.ParseHead(*headVal)
+		if err != nil {
+			return err
+		}
+		head = parsed
+	}
+
+	f, err := os.Open(*auditPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	last, n, err := audit.VerifyHead(f, head)
+	if err != nil {
+		return fmt.Errorf("%s: %d entries verified before: %w", *auditPath, n, err)
+	}
+	_, err = fmt.Fprintf(w, "%s: %d entries verified, head %s\n", *auditPath, n, last)
+	return err
+}